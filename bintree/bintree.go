@@ -0,0 +1,359 @@
+// Package bintree serializes phrase trees to a compact binary format so that
+// they can be cached and loaded again without lexing and parsing.
+//
+// An encoded tree starts with the magic "PHPT", a version byte, the uvarint
+// parser.TreeVersion and the Key of the source it was parsed from, followed
+// by the number of tokens, phrases, errors and children as uvarints. Then come
+// the tokens in tree order, each its uvarint type, the varint distance of its
+// offset from the end of the previous token and its uvarint length, and the
+// structure of the tree in prefix order, where types are uvarints too:
+//
+//	token   0
+//	phrase  1, type, uvarint number of children, children
+//	error   2, type, expected, uvarint index of the unexpected token + 1 or 0,
+//	        uvarint number of children, children
+//
+// Tokens of the structure take the tokens in order. Unexpected tokens of errors
+// which are not part of the tree come after the tokens of the tree.
+package bintree
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+// Version is the version of the format written by Encode. Decode returns
+// ErrVersion for data of another version or of another parser.TreeVersion.
+const Version = 2
+
+const magic = "PHPT"
+
+const (
+	tagToken byte = iota
+	tagPhrase
+	tagError
+)
+
+var (
+	ErrFormat  = errors.New("bintree: invalid data")
+	ErrVersion = errors.New("bintree: unsupported version")
+)
+
+// Key identifies the source of a tree by its SHA-256 hash
+type Key [sha256.Size]byte
+
+// KeyOf returns the key of source
+func KeyOf(source []byte) Key {
+	return sha256.Sum256(source)
+}
+
+func (k Key) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// Encode returns the encoding of a tree parsed from the source with the
+// given key
+func Encode(root *phrase.Phrase, key Key) []byte {
+	e := &encoder{indexes: map[*lexer.Token]int{}}
+	e.collect(root)
+	for _, err := range e.unexpected {
+		if _, ok := e.indexes[err.Unexpected]; !ok {
+			e.indexes[err.Unexpected] = len(e.tokens)
+			e.tokens = append(e.tokens, err.Unexpected)
+		}
+	}
+
+	buf := make([]byte, 0, len(magic)+1+len(key)+5*binary.MaxVarintLen64+len(e.tokens)*4+e.phrases*3)
+	buf = append(buf, magic...)
+	buf = append(buf, Version)
+	buf = appendUvarint(buf, parser.TreeVersion)
+	buf = append(buf, key[:]...)
+	buf = appendUvarint(buf, uint64(len(e.tokens)))
+	buf = appendUvarint(buf, uint64(e.phrases))
+	buf = appendUvarint(buf, uint64(e.errors))
+	buf = appendUvarint(buf, uint64(e.children))
+	end := 0
+	for _, t := range e.tokens {
+		buf = appendUvarint(buf, uint64(t.Type))
+		buf = appendVarint(buf, int64(t.Offset-end))
+		buf = appendUvarint(buf, uint64(t.Length))
+		end = t.Offset + t.Length
+	}
+	e.buf = buf
+	e.node(root)
+	return e.buf
+}
+
+type encoder struct {
+	buf     []byte
+	tokens  []*lexer.Token
+	indexes map[*lexer.Token]int
+	// unexpected are the errors with an unexpected token
+	unexpected []*phrase.ParseError
+	phrases    int
+	errors     int
+	children   int
+}
+
+// collect numbers the tokens of the tree and counts its nodes
+func (e *encoder) collect(node phrase.AstNode) {
+	var children []phrase.AstNode
+	switch node := node.(type) {
+	case *lexer.Token:
+		e.indexes[node] = len(e.tokens)
+		e.tokens = append(e.tokens, node)
+		return
+	case *phrase.Phrase:
+		e.phrases++
+		children = node.Children
+	case *phrase.ParseError:
+		e.errors++
+		if node.Unexpected != nil {
+			e.unexpected = append(e.unexpected, node)
+		}
+		children = node.Children
+	}
+	e.children += len(children)
+	for _, child := range children {
+		e.collect(child)
+	}
+}
+
+func (e *encoder) node(node phrase.AstNode) {
+	switch node := node.(type) {
+	case *lexer.Token:
+		e.buf = append(e.buf, tagToken)
+	case *phrase.Phrase:
+		e.buf = append(e.buf, tagPhrase)
+		e.buf = appendUvarint(e.buf, uint64(node.Type))
+		e.list(node.Children)
+	case *phrase.ParseError:
+		e.buf = append(e.buf, tagError)
+		e.buf = appendUvarint(e.buf, uint64(node.Type))
+		e.buf = appendUvarint(e.buf, uint64(node.Expected))
+		unexpected := 0
+		if node.Unexpected != nil {
+			unexpected = e.indexes[node.Unexpected] + 1
+		}
+		e.buf = appendUvarint(e.buf, uint64(unexpected))
+		e.list(node.Children)
+	}
+}
+
+func (e *encoder) list(children []phrase.AstNode) {
+	e.buf = appendUvarint(e.buf, uint64(len(children)))
+	for _, child := range children {
+		e.node(child)
+	}
+}
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], x)]...)
+}
+
+func appendVarint(buf []byte, x int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutVarint(tmp[:], x)]...)
+}
+
+// Decode returns the tree encoded in data and the key of its source. The tree
+// does not reference data.
+func Decode(data []byte) (Key, *phrase.Phrase, error) {
+	var key Key
+	if len(data) < len(magic)+1 || string(data[:len(magic)]) != magic {
+		return key, nil, ErrFormat
+	}
+	if data[len(magic)] != Version {
+		return key, nil, ErrVersion
+	}
+	d := &decoder{data: data, pos: len(magic) + 1}
+	if tree := d.uvarint(); d.err != nil {
+		return key, nil, d.err
+	} else if tree != parser.TreeVersion {
+		return key, nil, ErrVersion
+	}
+	if len(data) < d.pos+len(key) {
+		return key, nil, ErrFormat
+	}
+	copy(key[:], data[d.pos:])
+	d.pos += len(key)
+
+	// every node takes at least one byte, which bounds the allocations
+	tokens, phrases, errs, children := d.count(), d.count(), d.count(), d.count()
+	if d.err != nil {
+		return key, nil, d.err
+	}
+	d.tokens = make([]lexer.Token, tokens)
+	d.phrases = make([]phrase.Phrase, phrases)
+	d.errors = make([]phrase.ParseError, errs)
+	d.children = make([]phrase.AstNode, children)
+	end := 0
+	for i := range d.tokens {
+		t := &d.tokens[i]
+		t.Type = d.tokenType()
+		t.Offset = end + int(d.varint())
+		t.Length = int(d.uvarint())
+		if t.Offset < 0 || t.Length < 0 {
+			return key, nil, ErrFormat
+		}
+		end = t.Offset + t.Length
+	}
+
+	if d.err == nil && d.byte() != tagPhrase {
+		return key, nil, ErrFormat
+	}
+	root := d.phrase()
+	if d.err != nil {
+		return key, nil, d.err
+	}
+	if d.pos != len(d.data) || d.nextPhrase != phrases || d.nextError != errs || d.nextChild != children {
+		return key, nil, ErrFormat
+	}
+	return key, root, nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+	err  error
+
+	tokens   []lexer.Token
+	phrases  []phrase.Phrase
+	errors   []phrase.ParseError
+	children []phrase.AstNode
+	// the next token, phrase, error and child to use
+	nextToken, nextPhrase, nextError, nextChild int
+}
+
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = ErrFormat
+	}
+	d.pos = len(d.data)
+}
+
+func (d *decoder) byte() byte {
+	if d.pos >= len(d.data) {
+		d.fail()
+		return 0
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b
+}
+
+func (d *decoder) uvarint() uint64 {
+	x, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.pos += n
+	return x
+}
+
+func (d *decoder) varint() int64 {
+	x, n := binary.Varint(d.data[d.pos:])
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.pos += n
+	return x
+}
+
+// tokenType and phraseType fail on types out of the range of their type
+func (d *decoder) tokenType() lexer.TokenType {
+	x := d.uvarint()
+	if uint64(lexer.TokenType(x)) != x {
+		d.fail()
+	}
+	return lexer.TokenType(x)
+}
+
+func (d *decoder) phraseType() phrase.PhraseType {
+	x := d.uvarint()
+	if uint64(phrase.PhraseType(x)) != x {
+		d.fail()
+	}
+	return phrase.PhraseType(x)
+}
+
+func (d *decoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) phrase() *phrase.Phrase {
+	if d.nextPhrase >= len(d.phrases) {
+		d.fail()
+		return nil
+	}
+	p := &d.phrases[d.nextPhrase]
+	d.nextPhrase++
+	p.Type = d.phraseType()
+	p.Children = d.list()
+	return p
+}
+
+func (d *decoder) parseError() *phrase.ParseError {
+	if d.nextError >= len(d.errors) {
+		d.fail()
+		return nil
+	}
+	p := &d.errors[d.nextError]
+	d.nextError++
+	p.Type = d.phraseType()
+	p.Expected = d.tokenType()
+	if unexpected := d.uvarint(); unexpected > uint64(len(d.tokens)) {
+		d.fail()
+	} else if unexpected > 0 {
+		p.Unexpected = &d.tokens[unexpected-1]
+	}
+	p.Children = d.list()
+	return p
+}
+
+// list decodes children into the shared slice of children, capped so that
+// appending to them does not overwrite their neighbours
+func (d *decoder) list() []phrase.AstNode {
+	n := d.count()
+	if d.nextChild+n > len(d.children) {
+		d.fail()
+		return nil
+	}
+	children := d.children[d.nextChild : d.nextChild+n : d.nextChild+n]
+	d.nextChild += n
+	for i := range children {
+		switch d.byte() {
+		case tagToken:
+			if d.nextToken >= len(d.tokens) {
+				d.fail()
+				return nil
+			}
+			children[i] = &d.tokens[d.nextToken]
+			d.nextToken++
+		case tagPhrase:
+			children[i] = d.phrase()
+		case tagError:
+			children[i] = d.parseError()
+		default:
+			d.fail()
+		}
+		if d.err != nil {
+			return nil
+		}
+	}
+	return children
+}
@@ -0,0 +1,195 @@
+package bintree
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+)
+
+// corpus returns the sources of the cases which have parser snapshots
+func corpus(tb testing.TB) map[string][]byte {
+	files, err := ioutil.ReadDir("../.snapshots")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	sources := map[string][]byte{}
+	for _, file := range files {
+		name := strings.TrimPrefix(file.Name(), "TestParserAndLexer-")
+		if name == file.Name() {
+			continue
+		}
+		source, err := ioutil.ReadFile(filepath.Join("../cases", name+".php"))
+		if os.IsNotExist(err) {
+			// a snapshot of a removed case
+			continue
+		}
+		if err != nil {
+			tb.Fatal(err)
+		}
+		sources[name] = source
+	}
+	if len(sources) == 0 {
+		tb.Fatal("no cases")
+	}
+	return sources
+}
+
+func TestRoundTrip(t *testing.T) {
+	for name, source := range corpus(t) {
+		expected := parser.Parse(source)
+		data := Encode(expected, KeyOf(source))
+		key, actual, err := Decode(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if key != KeyOf(source) {
+			t.Errorf("%s: unexpected key %s", name, key)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: the tree differs after a round trip", name)
+		}
+	}
+}
+
+func TestUnexpectedOutsideTree(t *testing.T) {
+	source := []byte("<?php\nfunction")
+	expected := parser.Parse(source)
+	_, actual, err := Decode(Encode(expected, KeyOf(source)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("the tree differs after a round trip")
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	source := []byte("<?php\n$a = ;\nfunction foo() {}")
+	data := Encode(parser.Parse(source), KeyOf(source))
+	for i := 0; i < len(data); i++ {
+		if _, _, err := Decode(data[:i]); err == nil {
+			t.Errorf("expected an error for %d bytes", i)
+		}
+	}
+	if _, _, err := Decode(append(data[:len(data):len(data)], 0)); err != ErrFormat {
+		t.Errorf("expected ErrFormat for trailing data, got %v", err)
+	}
+	other := append([]byte{}, data...)
+	other[len(magic)]++
+	if _, _, err := Decode(other); err != ErrVersion {
+		t.Errorf("expected ErrVersion, got %v", err)
+	}
+	other = append([]byte{}, data...)
+	other[len(magic)+1]++
+	if _, _, err := Decode(other); err != ErrVersion {
+		t.Errorf("expected ErrVersion for another tree version, got %v", err)
+	}
+
+	// a root phrase whose type does not fit a PhraseType
+	var key Key
+	large := append([]byte(magic), Version)
+	large = appendUvarint(large, parser.TreeVersion)
+	large = append(large, key[:]...)
+	large = append(large, 0, 1, 0, 0, tagPhrase)
+	large = appendUvarint(large, 300)
+	large = append(large, 0)
+	if _, _, err := Decode(large); err != ErrFormat {
+		t.Errorf("expected ErrFormat for a type out of range, got %v", err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bintree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, err := NewCache(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := []byte("<?php\nclass A { function b() { return 1; } }")
+	key := KeyOf(source)
+	if _, err := cache.Load(key); !os.IsNotExist(err) {
+		t.Fatalf("expected no cached tree, got %v", err)
+	}
+	expected := cache.Parse(source)
+	actual, err := cache.Load(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("the cached tree differs")
+	}
+
+	// a tree stored under another key is rejected
+	other := KeyOf([]byte("<?php"))
+	data, _ := ioutil.ReadFile(cache.path(key))
+	ioutil.WriteFile(cache.path(other), data, 0644)
+	if _, err := cache.Load(other); err != ErrFormat {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+
+	// a tree of another parser version is parsed again and replaced
+	data[len(magic)+1]++
+	ioutil.WriteFile(cache.path(key), data, 0644)
+	if _, err := cache.Load(key); err != ErrVersion {
+		t.Errorf("expected ErrVersion, got %v", err)
+	}
+	if !reflect.DeepEqual(cache.Parse(source), expected) {
+		t.Errorf("the reparsed tree differs")
+	}
+	if _, err := cache.Load(key); err != nil {
+		t.Errorf("expected the stale tree to be replaced, got %v", err)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	sources := corpus(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, source := range sources {
+			parser.Parse(source)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	var encoded [][]byte
+	for _, source := range corpus(b) {
+		encoded = append(encoded, Encode(parser.Parse(source), KeyOf(source)))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, data := range encoded {
+			if _, _, err := Decode(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkCacheParse(b *testing.B) {
+	dir, err := ioutil.TempDir("", "bintree")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := &Cache{Dir: dir}
+	sources := corpus(b)
+	for _, source := range sources {
+		cache.Parse(source)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, source := range sources {
+			cache.Parse(source)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package bintree
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+// Cache stores encoded trees in a directory, one file per key
+type Cache struct {
+	Dir string
+}
+
+// NewCache returns a cache in dir, which is created if needed
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+func (c *Cache) path(key Key) string {
+	return filepath.Join(c.Dir, key.String()+".tree")
+}
+
+// Load returns the cached tree of key. The error satisfies os.IsNotExist when
+// there is none.
+func (c *Cache) Load(key Key) (*phrase.Phrase, error) {
+	data, release, err := mapFile(c.path(key))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	k, root, err := Decode(data)
+	if err == nil && k != key {
+		err = ErrFormat
+	}
+	return root, err
+}
+
+// Store caches the tree of key. The file is written under a temporary name
+// and renamed so that readers never see partial trees.
+func (c *Cache) Store(key Key, root *phrase.Phrase) error {
+	f, err := ioutil.TempFile(c.Dir, ".tree")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(Encode(root, key))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Parse returns the cached tree of source or parses and caches it. Errors of
+// the cache are ignored since the tree can always be parsed.
+func (c *Cache) Parse(source []byte) *phrase.Phrase {
+	key := KeyOf(source)
+	if root, err := c.Load(key); err == nil {
+		return root
+	}
+	root := parser.Parse(source)
+	c.Store(key, root)
+	return root
+}
@@ -0,0 +1,33 @@
+<?php
+
+/** Application constant */
+const VERSION = '1.0';
+
+/**
+ * @template T
+ */
+abstract class Repository
+{
+    /** @var int */
+    const LIMIT = 10;
+
+    /** @var T[] */
+    protected $items = [
+        /** the first item */
+        'first' => null,
+    ];
+
+    /** Finds an item */
+    // a line comment in between
+    abstract public function find($id);
+}
+
+/** @var Repository<User> $users */
+$users = make(Repository::class);
+
+/** Dangling comment */
+;
+
+function undocumented()
+{
+}
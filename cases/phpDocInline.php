@@ -0,0 +1,24 @@
+<?php
+
+/**
+ * Summary of the class, see {@link Foo::bar() the bar method}.
+ * It spans two lines and uses `array_map()` inline.
+ *
+ *
+ * A second paragraph with {@see \Baz\Qux} and
+ * an unterminated `backtick plus {@link nowhere
+ *
+ * @param string $x Keep {@inheritDoc} in tags
+ */
+class Documented
+{
+    /**
+     * {@inheritDoc}
+     */
+    public function bar()
+    {
+    }
+
+    /** {@inheritdoc} */
+    public $prop;
+}
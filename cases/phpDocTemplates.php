@@ -0,0 +1,39 @@
+<?php
+
+/**
+ * @template TKey of array-key
+ * @template-covariant TValue
+ * @psalm-template T as object
+ * @template TDefault = null Defaults to null
+ * @extends BaseRepository<int, User>
+ * @implements \IteratorAggregate<TKey, TValue>
+ * @template-implements Countable
+ * @mixin Builder<User>
+ */
+class UserRepository extends BaseRepository implements \IteratorAggregate
+{
+    /**
+     * @use HasFactory<UserFactory>
+     */
+    use HasFactory;
+
+    /**
+     * @psalm-param class-string<T> $class
+     * @phpstan-return T|null
+     * @param-out non-empty-string $name
+     * @psalm-suppress MixedReturnStatement
+     */
+    public function find($class, &$name)
+    {
+    }
+
+    /**
+     * @psalm-assert !null $value
+     * @phpstan-assert =int $count
+     * @psalm-assert-if-true User $this->user
+     * @phpstan-assert-if-false null $this->current() The cursor is exhausted
+     */
+    public function check($value, $count)
+    {
+    }
+}
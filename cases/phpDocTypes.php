@@ -0,0 +1,46 @@
+<?php
+
+/**
+ * @param array<int, User> $users
+ * @param list<string> $names
+ * @param non-empty-array<array-key, mixed> $map
+ * @param array{id: int, name?: string, 'e-mail': string, 0: bool} $row
+ * @param list{int, string} $pair
+ * @param callable(int, string=): void $callback
+ * @param Closure(): static $factory
+ * @param \Closure(int ...$rest): ?int $variadic
+ * @param int<0, max> $positive
+ * @param int<-1, 1> $sign
+ * @param 'a'|'b'|"c" $letter
+ * @param 1|2.5 $number
+ * @param class-string<T> $className
+ * @param key-of<T> $key
+ * @param Countable&Traversable $both
+ * @param (A|B)[] $grouped
+ * @param Generator<int, covariant Node, mixed, void> $gen
+ * @param Collection<*> $any
+ * @param Foo::BAR|Foo::BAZ_*|self::* $constant
+ * @param T['key'] $offset
+ * @param int &$byRef
+ * @param string ...$variadicParam
+ * @return ($size is positive-int ? non-empty-array<int> : array<int>)
+ */
+function types($users, $names, $map, $row)
+{
+}
+
+/**
+ * @return array{
+ *     id: int,
+ *     tags: list<string>,
+ *     ...
+ * }
+ */
+function multiline()
+{
+}
+
+/** @return $value is not null ? T : never Description that follows */
+function conditional($value)
+{
+}
@@ -0,0 +1,149 @@
+// Package charset detects the encoding of PHP sources and transcodes them to
+// UTF-8 while keeping offsets in the bytes of the original file.
+//
+// The lexer scans bytes the way PHP does, taking every byte from 0x80 on as
+// part of a label, so a source in a single-byte encoding such as ISO-8859-1
+// is lexed as it is and its tokens give offsets in the original bytes. A
+// Source maps those offsets to the UTF-8 text of the tokens and back.
+package charset
+
+import (
+	"bytes"
+	"sort"
+	"unicode/utf8"
+)
+
+// BOM is the UTF-8 byte order mark
+const BOM = "\xEF\xBB\xBF"
+
+// Encoding is an encoding of sources
+type Encoding uint8
+
+const (
+	UTF8 Encoding = iota
+	ISO88591
+	Windows1252
+)
+
+var /* const */ encodingStrings = []string{
+	"UTF-8",
+	"ISO-8859-1",
+	"Windows-1252",
+}
+
+func (enc Encoding) String() string {
+	if int(enc) >= len(encodingStrings) {
+		return "Unknown"
+	}
+	return encodingStrings[int(enc)]
+}
+
+// windows1252 are the characters of the bytes 0x80 to 0x9F in Windows-1252.
+// The bytes it leaves undefined decode to the control characters of the
+// same code, as in ISO-8859-1.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// HasBOM reports whether source starts with the UTF-8 byte order mark
+func HasBOM(source []byte) bool {
+	return bytes.HasPrefix(source, []byte(BOM))
+}
+
+// Detect guesses the encoding of source. Sources with a byte order mark or
+// which are valid UTF-8 are UTF-8. Others are Windows-1252 when they use its
+// characters from 0x80 to 0x9F, which are control characters in ISO-8859-1,
+// and ISO-8859-1 otherwise.
+func Detect(source []byte) Encoding {
+	if HasBOM(source) || utf8.Valid(source) {
+		return UTF8
+	}
+	for _, b := range source {
+		if b >= 0x80 && b <= 0x9F {
+			return Windows1252
+		}
+	}
+	return ISO88591
+}
+
+// decodeByte returns the character of a byte from 0x80 on
+func (enc Encoding) decodeByte(b byte) rune {
+	if enc == Windows1252 && b <= 0x9F {
+		return windows1252[b-0x80]
+	}
+	return rune(b)
+}
+
+// Source is a source and its text in UTF-8
+type Source struct {
+	Original []byte
+	Encoding Encoding
+	// Text is the original transcoded to UTF-8, or the original itself when
+	// it is UTF-8
+	Text []byte
+	// originals are the offsets of the bytes from 0x80 on in the original
+	// and texts their offsets in Text
+	originals []int
+	texts     []int
+}
+
+// NewSource transcodes original from enc to UTF-8
+func NewSource(original []byte, enc Encoding) *Source {
+	s := &Source{Original: original, Encoding: enc, Text: original}
+	if enc == UTF8 {
+		return s
+	}
+
+	text := make([]byte, 0, len(original)+len(original)/8)
+	for i, b := range original {
+		if b < utf8.RuneSelf {
+			text = append(text, b)
+			continue
+		}
+		s.originals = append(s.originals, i)
+		s.texts = append(s.texts, len(text))
+		var buf [utf8.UTFMax]byte
+		text = append(text, buf[:utf8.EncodeRune(buf[:], enc.decodeByte(b))]...)
+	}
+	s.Text = text
+	return s
+}
+
+// Decode detects the encoding of original and transcodes it to UTF-8
+func Decode(original []byte) *Source {
+	return NewSource(original, Detect(original))
+}
+
+// TextOffset returns the offset in Text of an offset in the original
+func (s *Source) TextOffset(offset int) int {
+	// the non-ASCII bytes before offset
+	i := sort.SearchInts(s.originals, offset)
+	if i == 0 {
+		return offset
+	}
+	// the bytes after the last of them are ASCII
+	return s.texts[i-1] + utf8.RuneLen(s.Encoding.decodeByte(s.Original[s.originals[i-1]])) +
+		offset - s.originals[i-1] - 1
+}
+
+// OriginalOffset returns the offset in the original of an offset in Text.
+// Offsets inside a character are taken to its start.
+func (s *Source) OriginalOffset(offset int) int {
+	i := sort.Search(len(s.texts), func(i int) bool { return s.texts[i] > offset })
+	if i == 0 {
+		return offset
+	}
+	last := s.originals[i-1]
+	width := utf8.RuneLen(s.Encoding.decodeByte(s.Original[last]))
+	if offset < s.texts[i-1]+width {
+		return last
+	}
+	return last + 1 + offset - s.texts[i-1] - width
+}
+
+// Span returns the UTF-8 text of the original bytes from start to end, such
+// as the text of a token, sharing the bytes of Text
+func (s *Source) Span(start int, end int) []byte {
+	return s.Text[s.TextOffset(start):s.TextOffset(end)]
+}
@@ -0,0 +1,92 @@
+package charset
+
+import (
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+)
+
+func TestDetect(t *testing.T) {
+	for source, expected := range map[string]Encoding{
+		"<?php echo 'a';":           UTF8,
+		"<?php echo 'é';":           UTF8,
+		BOM + "<?php echo '\xE9';":  UTF8,
+		"<?php echo 'caf\xE9';":     ISO88591,
+		"<?php echo '\x80 caf\xE9'": Windows1252,
+	} {
+		if actual := Detect([]byte(source)); actual != expected {
+			t.Errorf("%q: expected %v, got %v", source, expected, actual)
+		}
+	}
+}
+
+func TestNewSource(t *testing.T) {
+	for _, test := range []struct {
+		original string
+		enc      Encoding
+		text     string
+	}{
+		{"a\xE9b\xFF", ISO88591, "aébÿ"},
+		{"\x80\x81\x83 \x9F\xA0", Windows1252, "€\u0081ƒ Ÿ "},
+		{"\x80\x81", ISO88591, "\u0080\u0081"},
+		{"aé", UTF8, "aé"},
+	} {
+		s := NewSource([]byte(test.original), test.enc)
+		if string(s.Text) != test.text {
+			t.Errorf("%q as %v: expected %q, got %q", test.original, test.enc, test.text, s.Text)
+		}
+
+		// every original offset maps to the start of its character and back
+		for i := 0; i <= len(test.original); i++ {
+			offset := s.TextOffset(i)
+			if i < len(test.original) && test.enc != UTF8 {
+				if r := []rune(string(s.Text[offset:]))[0]; r != []rune(test.text)[i] {
+					t.Errorf("%q as %v: expected %q at %d, got %q", test.original, test.enc, []rune(test.text)[i], i, r)
+				}
+			}
+			if back := s.OriginalOffset(offset); back != i {
+				t.Errorf("%q as %v: %d maps to %d and back to %d", test.original, test.enc, i, offset, back)
+			}
+		}
+		if s.TextOffset(len(test.original)) != len(s.Text) {
+			t.Errorf("%q as %v: expected the end to map to the end", test.original, test.enc)
+		}
+	}
+
+	s := NewSource([]byte("\xE9"), ISO88591)
+	if offset := s.OriginalOffset(1); offset != 0 {
+		t.Errorf("expected an offset inside a character to map to its start, got %d", offset)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	original := []byte("<?php\n// \x93r\xE9sum\xE9\x94\n$caf\xE9 = '\x80 \xE0 la carte';\n")
+	s := Decode(original)
+	if s.Encoding != Windows1252 {
+		t.Fatalf("expected Windows-1252, got %v", s.Encoding)
+	}
+
+	// lexing the original gives the tokens of the UTF-8 text, with offsets
+	// in the original
+	expected := []lexer.Token{}
+	for it := lexer.Tokens(s.Text); it.Next(); {
+		expected = append(expected, it.Token())
+	}
+	texts := []string{}
+	i := 0
+	for it := lexer.Tokens(original); it.Next(); i++ {
+		token := it.Token()
+		if i >= len(expected) || token.Type != expected[i].Type ||
+			s.TextOffset(token.Offset) != expected[i].Offset ||
+			len(s.Span(token.Offset, token.Offset+token.Length)) != expected[i].Length {
+			t.Fatalf("token %d: %v does not match %v", i, token, expected)
+		}
+		texts = append(texts, string(s.Span(token.Offset, token.Offset+token.Length)))
+	}
+	if i != len(expected) {
+		t.Errorf("expected %d tokens, got %d", len(expected), i)
+	}
+	if texts[1] != "// “résumé”" || texts[3] != "$café" || texts[7] != "'€ à la carte'" {
+		t.Errorf("unexpected texts %q", texts)
+	}
+}
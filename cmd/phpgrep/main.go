@@ -0,0 +1,163 @@
+// Command phpgrep searches PHP files for code matching a pattern written as
+// PHP, see package query for the syntax.
+//
+//	phpgrep [-l] [-s] pattern [path ...]
+//
+// Directories are searched recursively for .php files, the current directory
+// by default. Each match is printed as file:line:column: followed by its first
+// line and the bindings of its metavariables. The exit status is 0 if there was
+// a match, 1 if there was none and 2 on error.
+//
+// With -s the pattern is a query in the S-expression syntax of query.Selector
+// and the captures are printed instead of bindings.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/position"
+	"github.com/john-nguyen09/go-phpparser/query"
+)
+
+var (
+	listFiles = flag.Bool("l", false, "only print the names of files with matches")
+	selector  = flag.Bool("s", false, "the pattern is an S-expression query")
+)
+
+// matcher returns the matches of a pattern in a file as their offsets and
+// descriptions of their bindings or captures
+type matcher func(source []byte) []match
+
+type match struct {
+	start, end int
+	bindings   []string
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: phpgrep [-l] [-s] pattern [path ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var m matcher
+	var err error
+	if *selector {
+		m, err = compileSelector(flag.Arg(0))
+	} else {
+		m, err = compilePattern(flag.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	paths := flag.Args()[1:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	status := 1
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || path != root && filepath.Ext(path) != ".php" {
+				return nil
+			}
+			found, err := grep(m, path)
+			if found && status == 1 {
+				status = 0
+			}
+			return err
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+		}
+	}
+	os.Exit(status)
+}
+
+func compilePattern(text string) (matcher, error) {
+	pattern, err := query.Compile(text)
+	if err != nil {
+		return nil, err
+	}
+	return func(source []byte) []match {
+		var result []match
+		for _, m := range pattern.Match(parser.Parse(source), source) {
+			names := []string{}
+			for name := range m.Bindings {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			var bindings []string
+			for _, name := range names {
+				bindings = append(bindings, name+"="+m.Bindings[name].Text(source))
+			}
+			result = append(result, match{m.Start, m.End, bindings})
+		}
+		return result
+	}, nil
+}
+
+func compileSelector(text string) (matcher, error) {
+	s, err := query.CompileSelector(text)
+	if err != nil {
+		return nil, err
+	}
+	return func(source []byte) []match {
+		var result []match
+		for _, m := range s.Match(parser.Parse(source), source) {
+			if len(m.Captures) == 0 {
+				continue
+			}
+			var captures []string
+			for _, c := range m.Captures {
+				captures = append(captures, "@"+c.Name+"="+string(source[c.Start:c.End]))
+			}
+			result = append(result, match{m.Captures[0].Start, m.Captures[0].End, captures})
+		}
+		return result
+	}, nil
+}
+
+func grep(m matcher, path string) (bool, error) {
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	matches := m(source)
+	if len(matches) == 0 {
+		return false, nil
+	}
+	if *listFiles {
+		fmt.Println(path)
+		return true, nil
+	}
+
+	index := position.NewIndex(source)
+	for _, m := range matches {
+		pos := index.Position(m.start)
+		text := string(source[m.start:m.end])
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[:i] + " ..."
+		}
+		for _, binding := range m.bindings {
+			text += "  " + strings.Join(strings.Fields(binding), " ")
+		}
+		fmt.Printf("%s:%d:%d: %s\n", path, pos.Line+1, pos.Character+1, text)
+	}
+	return true, nil
+}
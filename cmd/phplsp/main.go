@@ -0,0 +1,22 @@
+// Command phplsp is a PHP language server speaking the Language Server
+// Protocol over stdin and stdout
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/john-nguyen09/go-phpparser/lsp"
+)
+
+func main() {
+	err := lsp.NewServer(os.Stdin, os.Stdout).Run()
+	if err == nil {
+		return
+	}
+	if err != io.EOF && err != lsp.ErrExitWithoutShutdown {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,206 @@
+// Package control gives the parts of control structures the same way for the
+// brace syntax, if (...) { ... }, and the alternative syntax of templates,
+// if (...): ... endif;, whose phrases lay their children out differently.
+package control
+
+import (
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+type Syntax uint8
+
+const (
+	// Standard is a body in braces, or a single statement
+	Standard Syntax = iota
+	// Alternative is a body after a colon, closed by endif, endwhile,
+	// endfor, endforeach, endswitch or enddeclare
+	Alternative
+)
+
+//go:generate stringer -type=Syntax
+
+// Structure is a control structure, or an elseif or else clause of an if
+// statement
+type Structure struct {
+	Phrase *phrase.Phrase
+}
+
+// Of returns the structure of an IfStatement, ElseIfClause, ElseClause,
+// WhileStatement, DoStatement, ForStatement, ForeachStatement,
+// SwitchStatement or DeclareStatement
+func Of(p *phrase.Phrase) (Structure, bool) {
+	switch p.Type {
+	case phrase.IfStatement,
+		phrase.ElseIfClause,
+		phrase.ElseClause,
+		phrase.WhileStatement,
+		phrase.DoStatement,
+		phrase.ForStatement,
+		phrase.ForeachStatement,
+		phrase.SwitchStatement,
+		phrase.DeclareStatement:
+		return Structure{p}, true
+	}
+	return Structure{}, false
+}
+
+// Syntax returns the syntax the structure is written in
+func (s Structure) Syntax() Syntax {
+	if s.token(lexer.Colon) != nil {
+		return Alternative
+	}
+	return Standard
+}
+
+// Keyword returns the keyword starting the structure, such as if or else
+func (s Structure) Keyword() *lexer.Token {
+	return phrase.FirstToken(s.Phrase)
+}
+
+// End returns the endif, endwhile, endfor, endforeach, endswitch or
+// enddeclare closing a structure in the alternative syntax, or nil
+func (s Structure) End() *lexer.Token {
+	for _, t := range []lexer.TokenType{lexer.EndIf, lexer.EndWhile, lexer.EndFor,
+		lexer.EndForeach, lexer.EndSwitch, lexer.EndDeclare} {
+		if end := s.token(t); end != nil {
+			return end
+		}
+	}
+	return nil
+}
+
+// Condition returns the expression in the parentheses of an if, elseif,
+// while, do-while or switch, and the ForControl of a for. Else, foreach and
+// declare, and a for without a condition have none and give nil.
+func (s Structure) Condition() phrase.AstNode {
+	switch s.Phrase.Type {
+	case phrase.IfStatement, phrase.ElseIfClause, phrase.WhileStatement,
+		phrase.DoStatement, phrase.SwitchStatement:
+		open := false
+		for _, child := range s.Phrase.Children {
+			t, ok := child.(*lexer.Token)
+			switch {
+			case !open:
+				open = ok && t.Type == lexer.OpenParenthesis
+			case !ok:
+				return child
+			case t.Type == lexer.CloseParenthesis:
+				return nil
+			case t.Type != lexer.Comment && t.Type != lexer.Whitespace:
+				// a literal
+				return t
+			}
+		}
+	case phrase.ForStatement:
+		if control := s.phrase(phrase.ForControl); control != nil {
+			return control
+		}
+	}
+	return nil
+}
+
+// Body returns the body of the structure: a CompoundStatement or a single
+// statement in the standard syntax, a StatementList in the alternative one,
+// and the CaseStatementList of a switch in both. It is nil when missing.
+func (s Structure) Body() phrase.AstNode {
+	if s.Phrase.Type == phrase.SwitchStatement {
+		if cases := s.phrase(phrase.CaseStatementList); cases != nil {
+			return cases
+		}
+		return nil
+	}
+	// the body follows the closing parenthesis, or the keyword of else and do
+	header := s.Phrase.Type == phrase.ElseClause || s.Phrase.Type == phrase.DoStatement
+	for _, child := range s.Phrase.Children {
+		if t, ok := child.(*lexer.Token); ok {
+			header = header || t.Type == lexer.CloseParenthesis
+			continue
+		}
+		if !header {
+			continue
+		}
+		if p, ok := child.(*phrase.Phrase); ok &&
+			(p.Type == phrase.ElseIfClauseList || p.Type == phrase.ElseClause) {
+			return nil
+		}
+		return child
+	}
+	return nil
+}
+
+// Statements returns the statements of the body in both syntaxes, or the
+// CaseStatements of a switch. An else if in the standard syntax is an else
+// whose statement is an if statement.
+func (s Structure) Statements() []phrase.AstNode {
+	result := []phrase.AstNode{}
+	body := s.Body()
+	if p, ok := body.(*phrase.Phrase); ok {
+		switch p.Type {
+		case phrase.CompoundStatement:
+			for _, child := range p.Children {
+				if list, ok := child.(*phrase.Phrase); ok && list.Type == phrase.StatementList {
+					return statements(list)
+				}
+			}
+			return result
+		case phrase.StatementList, phrase.CaseStatementList:
+			return statements(p)
+		}
+	}
+	if body != nil {
+		result = append(result, body)
+	}
+	return result
+}
+
+func statements(list *phrase.Phrase) []phrase.AstNode {
+	result := []phrase.AstNode{}
+	for _, child := range list.Children {
+		if _, ok := child.(*lexer.Token); !ok {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+// ElseIfs returns the elseif clauses of an if statement
+func (s Structure) ElseIfs() []Structure {
+	result := []Structure{}
+	if list := s.phrase(phrase.ElseIfClauseList); list != nil {
+		for _, child := range list.Children {
+			if p, ok := child.(*phrase.Phrase); ok && p.Type == phrase.ElseIfClause {
+				result = append(result, Structure{p})
+			}
+		}
+	}
+	return result
+}
+
+// Else returns the else clause of an if statement
+func (s Structure) Else() (Structure, bool) {
+	if p := s.phrase(phrase.ElseClause); p != nil {
+		return Structure{p}, true
+	}
+	return Structure{}, false
+}
+
+// token returns the child token of type t
+func (s Structure) token(t lexer.TokenType) *lexer.Token {
+	for _, child := range s.Phrase.Children {
+		if token, ok := child.(*lexer.Token); ok && token.Type == t {
+			return token
+		}
+	}
+	return nil
+}
+
+// phrase returns the child phrase of type t
+func (s Structure) phrase(t phrase.PhraseType) *phrase.Phrase {
+	for _, child := range s.Phrase.Children {
+		if p, ok := child.(*phrase.Phrase); ok && p.Type == t {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package control
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+func TestSyntaxes(t *testing.T) {
+	tests := []struct {
+		standard    string
+		alternative string
+		expected    string
+	}{
+		{
+			"if ($a) { x(); y(); } elseif ($b) z(); elseif ($c) {} else { w(); }",
+			"if ($a): x(); y(); elseif ($b): z(); elseif ($c): else: w(); endif;",
+			"if ($a) [x(); y();] elseif ($b) [z();] elseif ($c) [] else [w();]",
+		},
+		{"if ($a) x();", "if ($a): x(); endif;", "if ($a) [x();]"},
+		{"while ($a) { x(); }", "while ($a): x(); endwhile;", "while ($a) [x();]"},
+		{"while ($a) x();", "while ($a): x(); endwhile;", "while ($a) [x();]"},
+		{"for ($i = 0; $i < 3; $i++) {}", "for ($i = 0; $i < 3; $i++): endfor;", "for ($i < 3) []"},
+		{"for (;;) { x(); }", "for (;;): x(); endfor;", "for [x();]"},
+		{"foreach ($a as $k => $v) { x(); }", "foreach ($a as $k => $v): x(); endforeach;", "foreach [x();]"},
+		{"switch ($a) { case 1: x(); default: }", "switch ($a): case 1: x(); default: endswitch;",
+			"switch ($a) [case 1: x(); default:]"},
+		{"declare(ticks=1) { x(); }", "declare(ticks=1): x(); enddeclare;", "declare [x();]"},
+		// literal conditions are tokens
+		{"while (1) {}", "while (1): endwhile;", "while (1) []"},
+		{"if ( /* a */ 'a') {} elseif (0) {}", "if ( /* a */ 'a'): elseif (0): endif;", "if ('a') [] elseif (0) []"},
+		{"switch (1) {}", "switch (1): endswitch;", "switch (1) []"},
+	}
+
+	for _, test := range tests {
+		for syntax, source := range []string{test.standard, test.alternative} {
+			s, src := parseStructure(t, source)
+			if s.Syntax() != Syntax(syntax) {
+				t.Errorf("%q: expected %v, got %v", source, Syntax(syntax), s.Syntax())
+			}
+			if actual := describe(s, src); actual != test.expected {
+				t.Errorf("%q: expected %q, got %q", source, test.expected, actual)
+			}
+			if end := s.End(); (end != nil) != (s.Syntax() == Alternative) {
+				t.Errorf("%q: unexpected end %v", source, end)
+			}
+			if s.Syntax() == Alternative && !strings.HasPrefix(text(s.End(), src), "end") {
+				t.Errorf("%q: unexpected end %q", source, text(s.End(), src))
+			}
+		}
+	}
+}
+
+func TestOthers(t *testing.T) {
+	for source, expected := range map[string]string{
+		"do { x(); } while ($a);":      "do ($a) [x();]",
+		"do x(); while ($a);":          "do ($a) [x();]",
+		"do {} while (0);":             "do (0) []",
+		"declare(strict_types=1);":     "declare [;]",
+		"if ($a) x(); else if ($b) {}": "if ($a) [x();] else [if ($b) {}]",
+		"if ($a)":                      "if ($a) []",
+	} {
+		s, src := parseStructure(t, source)
+		if actual := describe(s, src); actual != expected {
+			t.Errorf("%q: expected %q, got %q", source, expected, actual)
+		}
+	}
+
+	if _, ok := Of(&phrase.Phrase{Type: phrase.ExpressionStatement}); ok {
+		t.Errorf("expected no structure of an expression statement")
+	}
+}
+
+func parseStructure(t *testing.T, source string) (Structure, []byte) {
+	src := []byte("<?php " + source)
+	root := parser.Parse(src)
+	for _, child := range root.Children {
+		if p, ok := child.(*phrase.Phrase); ok {
+			if s, ok := Of(p); ok {
+				return s, src
+			}
+		}
+	}
+	t.Fatalf("%q: no control structure", source)
+	return Structure{}, nil
+}
+
+// describe writes the structure the same way for both syntaxes
+func describe(s Structure, src []byte) string {
+	result := text(s.Keyword(), src)
+	if condition := s.Condition(); condition != nil {
+		result += " (" + text(condition, src) + ")"
+	}
+	statements := []string{}
+	for _, statement := range s.Statements() {
+		statements = append(statements, text(statement, src))
+	}
+	result += " [" + strings.Join(statements, " ") + "]"
+	for _, elseIf := range s.ElseIfs() {
+		result += " " + describe(elseIf, src)
+	}
+	if e, ok := s.Else(); ok {
+		result += " " + describe(e, src)
+	}
+	return result
+}
+
+func text(node phrase.AstNode, src []byte) string {
+	first, last := phrase.FirstToken(node), phrase.LastToken(node)
+	if first == nil {
+		return ""
+	}
+	return string(src[first.Offset : last.Offset+last.Length])
+}
@@ -0,0 +1,38 @@
+// Code generated by "stringer -type=Category"; DO NOT EDIT.
+
+package highlight
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[None-0]
+	_ = x[Keyword-1]
+	_ = x[Namespace-2]
+	_ = x[ClassName-3]
+	_ = x[Function-4]
+	_ = x[Method-5]
+	_ = x[Property-6]
+	_ = x[Parameter-7]
+	_ = x[Variable-8]
+	_ = x[Constant-9]
+	_ = x[String-10]
+	_ = x[Number-11]
+	_ = x[Comment-12]
+	_ = x[DocTag-13]
+	_ = x[DocType-14]
+	_ = x[Operator-15]
+}
+
+const _Category_name = "NoneKeywordNamespaceClassNameFunctionMethodPropertyParameterVariableConstantStringNumberCommentDocTagDocTypeOperator"
+
+var _Category_index = [...]uint8{0, 4, 11, 20, 29, 37, 43, 51, 60, 68, 76, 82, 88, 95, 101, 108, 116}
+
+func (i Category) String() string {
+	if i >= Category(len(_Category_index)-1) {
+		return "Category(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Category_name[_Category_index[i]:_Category_index[i+1]]
+}
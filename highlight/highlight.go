@@ -0,0 +1,295 @@
+// Package highlight classifies tokens for syntax highlighting using their
+// place in the parse tree, and encodes or renders the result.
+package highlight
+
+import (
+	"strings"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+	"github.com/john-nguyen09/go-phpparser/scope"
+)
+
+type Category uint8
+
+const (
+	None Category = iota
+	Keyword
+	Namespace
+	ClassName
+	Function
+	Method
+	Property
+	Parameter
+	Variable
+	Constant
+	String
+	Number
+	Comment
+	DocTag
+	DocType
+	Operator
+)
+
+//go:generate stringer -type=Category
+
+// Token is a classified lexer token. Declaration is set where the token names
+// the class, function, method, property, constant or parameter it declares.
+type Token struct {
+	Offset      int
+	Length      int
+	Category    Category
+	Declaration bool
+}
+
+var builtinTypes = map[string]bool{
+	"array": true, "bool": true, "callable": true, "false": true, "float": true,
+	"int": true, "iterable": true, "mixed": true, "never": true, "null": true,
+	"object": true, "parent": true, "self": true, "static": true, "string": true,
+	"true": true, "void": true,
+}
+
+type classifier struct {
+	source []byte
+	// variables maps the VariableName tokens of parameters to Parameter
+	variables map[*lexer.Token]Category
+	tokens    []Token
+	ancestors []*phrase.Phrase
+}
+
+// Classify returns every token of the tree in source order with its category,
+// None for whitespace and punctuation
+func Classify(root *phrase.Phrase, source []byte) []Token {
+	c := &classifier{source: source, variables: map[*lexer.Token]Category{}}
+	c.parameters(scope.Analyse(root, source))
+	c.visit(root)
+	return c.tokens
+}
+
+func (c *classifier) parameters(s *scope.Scope) {
+	for _, b := range s.Bindings {
+		if b.Kind != scope.Parameter {
+			continue
+		}
+		for _, ref := range b.References {
+			c.variables[ref.Token] = Parameter
+		}
+	}
+	for _, child := range s.Children {
+		c.parameters(child)
+	}
+}
+
+func (c *classifier) visit(node phrase.AstNode) {
+	switch node := node.(type) {
+	case *lexer.Token:
+		category, declaration := c.classify(node)
+		c.tokens = append(c.tokens, Token{node.Offset, node.Length, category, declaration})
+	case *phrase.Phrase:
+		c.visitChildren(node, node.Children)
+	case *phrase.ParseError:
+		c.visitChildren(&node.Phrase, node.Children)
+	}
+}
+
+func (c *classifier) visitChildren(p *phrase.Phrase, children []phrase.AstNode) {
+	c.ancestors = append(c.ancestors, p)
+	for _, child := range children {
+		c.visit(child)
+	}
+	c.ancestors = c.ancestors[:len(c.ancestors)-1]
+}
+
+// ancestor returns the nth ancestor of the current token, 0 being its parent
+func (c *classifier) ancestor(n int) phrase.PhraseType {
+	if n >= len(c.ancestors) {
+		return phrase.Unknown
+	}
+	return c.ancestors[len(c.ancestors)-1-n].Type
+}
+
+func (c *classifier) inDocComment() bool {
+	for i := len(c.ancestors) - 1; i >= 0; i-- {
+		if c.ancestors[i].Type == phrase.DocumentComment {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *classifier) classify(t *lexer.Token) (Category, bool) {
+	if t.Type == lexer.Whitespace {
+		return None, false
+	}
+	if c.inDocComment() {
+		return c.classifyDoc(t), false
+	}
+
+	switch c.ancestor(0) {
+	case phrase.Identifier, phrase.MemberName:
+		// keywords are valid member names, e.g. $a->list()
+		return c.classifyMember(), c.ancestor(1) == phrase.MethodDeclarationHeader ||
+			c.ancestor(1) == phrase.ClassConstElement
+	}
+
+	switch t.Type {
+	case lexer.Name:
+		return c.classifyName(t)
+	case lexer.VariableName:
+		return c.classifyVariable(t)
+	case lexer.StringLiteral, lexer.EncapsulatedAndWhitespace,
+		lexer.StartHeredoc, lexer.EndHeredoc, lexer.DoubleQuote, lexer.Backtick:
+		return String, false
+	case lexer.IntegerLiteral, lexer.FloatingLiteral:
+		return Number, false
+	case lexer.Comment:
+		return Comment, false
+	case lexer.OpenTag, lexer.OpenTagEcho, lexer.CloseTag:
+		return Keyword, false
+	case lexer.Semicolon, lexer.Comma, lexer.Dollar,
+		lexer.OpenBrace, lexer.CloseBrace,
+		lexer.OpenBracket, lexer.CloseBracket,
+		lexer.OpenParenthesis, lexer.CloseParenthesis,
+		lexer.CurlyOpen, lexer.DollarCurlyOpen:
+		return None, false
+	case lexer.Backslash:
+		if c.ancestor(0) == phrase.NamespaceName || c.ancestor(0) == phrase.FullyQualifiedName {
+			return Namespace, false
+		}
+	}
+
+	switch {
+	case t.Type >= lexer.Abstract && t.Type <= lexer.TraitConstant,
+		t.Type >= lexer.BooleanCast && t.Type <= lexer.FloatCast,
+		t.Type == lexer.ArrayCast:
+		return Keyword, false
+	case t.Type >= lexer.Equals && t.Type <= lexer.Backslash:
+		return Operator, false
+	}
+	return None, false
+}
+
+// classifyMember handles names under Identifier and MemberName
+func (c *classifier) classifyMember() Category {
+	switch c.ancestor(1) {
+	case phrase.MethodDeclarationHeader, phrase.MethodCallExpression:
+		return Method
+	case phrase.PropertyAccessExpression:
+		return Property
+	case phrase.ClassConstElement:
+		return Constant
+	case phrase.ScopedMemberName:
+		switch c.ancestor(2) {
+		case phrase.ScopedCallExpression:
+			return Method
+		case phrase.ClassConstantAccessExpression:
+			return Constant
+		}
+	}
+	return None
+}
+
+func (c *classifier) classifyName(t *lexer.Token) (Category, bool) {
+	switch c.ancestor(0) {
+	case phrase.ClassDeclarationHeader, phrase.InterfaceDeclarationHeader, phrase.TraitDeclarationHeader:
+		return ClassName, true
+	case phrase.FunctionDeclarationHeader:
+		return Function, true
+	case phrase.ConstElement:
+		return Constant, true
+	case phrase.ArgumentExpressionList:
+		// named argument
+		return Parameter, false
+	case phrase.NamespaceName:
+		return c.classifyQualifiedName(t), false
+	}
+	return None, false
+}
+
+// classifyQualifiedName classifies the last part of a name by what it refers
+// to, the parts before it being namespaces
+func (c *classifier) classifyQualifiedName(t *lexer.Token) Category {
+	namespaceName := c.ancestors[len(c.ancestors)-1]
+	if phrase.LastToken(namespaceName) != t {
+		return Namespace
+	}
+
+	context := 1
+	switch c.ancestor(1) {
+	case phrase.QualifiedName, phrase.FullyQualifiedName, phrase.RelativeQualifiedName:
+		context = 2
+	}
+	switch c.ancestor(context) {
+	case phrase.NamespaceDefinition:
+		return Namespace
+	case phrase.FunctionCallExpression:
+		return Function
+	case phrase.ConstantAccessExpression:
+		return Constant
+	case phrase.NamespaceUseClause, phrase.NamespaceUseGroupClause:
+		for i := len(c.ancestors) - 1; i >= 0; i-- {
+			if c.ancestors[i].Type != phrase.NamespaceUseDeclaration {
+				continue
+			}
+			for _, child := range c.ancestors[i].Children {
+				if t, ok := child.(*lexer.Token); ok {
+					switch t.Type {
+					case lexer.Function:
+						return Function
+					case lexer.Const:
+						return Constant
+					}
+				}
+			}
+		}
+		return ClassName
+	case phrase.TypeDeclaration, phrase.ClassTypeDesignator:
+		if builtinTypes[strings.ToLower(string(c.source[t.Offset:t.Offset+t.Length]))] {
+			return Keyword
+		}
+	}
+	return ClassName
+}
+
+func (c *classifier) classifyVariable(t *lexer.Token) (Category, bool) {
+	switch c.ancestor(0) {
+	case phrase.ParameterDeclaration:
+		return Parameter, true
+	case phrase.PropertyElement:
+		return Property, true
+	case phrase.ScopedMemberName:
+		return Property, false
+	}
+	if category, ok := c.variables[t]; ok {
+		return category, false
+	}
+	return Variable, false
+}
+
+func (c *classifier) classifyDoc(t *lexer.Token) Category {
+	if (t.Type > lexer.DocumentCommentTagNameAnchorStart && t.Type < lexer.DocumentCommentTagNameAnchorEnd) ||
+		t.Type == lexer.DocumentCommentTagName {
+		return DocTag
+	}
+	if t.Type == lexer.VariableName {
+		return Variable
+	}
+	for i := len(c.ancestors) - 1; i >= 0; i-- {
+		switch c.ancestors[i].Type {
+		case phrase.DocumentCommentReference:
+			if t.Type == lexer.Name {
+				return DocType
+			}
+			return Comment
+		case phrase.DocumentCommentDescription, phrase.DocumentComment:
+			return Comment
+		case phrase.TypeDeclaration:
+			switch t.Type {
+			case lexer.Name, lexer.Backslash, lexer.Static, lexer.Array:
+				return DocType
+			}
+			return Comment
+		}
+	}
+	return Comment
+}
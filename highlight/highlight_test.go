@@ -0,0 +1,167 @@
+package highlight
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/position"
+)
+
+const source = `<?php
+namespace App\Models;
+use Foo\Bar;
+use function Foo\helper;
+/**
+ * @param \Foo\Baz|null $x the x {@see Bar::list()}
+ */
+function f(int $x, Bar $y, string ...$rest): ?string {
+    $local = new \Foo\Baz();
+    $y->list()->prop;
+    Bar::CONST_A + Bar::create() + Bar::$shared;
+    helper(name: $x, other: PHP_EOL);
+    return $local instanceof Bar ? 'yes' : "no $x";
+}
+class User extends Bar {
+    const LIMIT = 10;
+    public $name;
+    public function list() {}
+}
+// done
+`
+
+func classify() []Token {
+	return Classify(parser.Parse([]byte(source)), []byte(source))
+}
+
+// categories returns the categories of the non whitespace tokens with the
+// given text, in order
+func categories(tokens []Token, text string) string {
+	result := []string{}
+	for _, t := range tokens {
+		if source[t.Offset:t.Offset+t.Length] != text {
+			continue
+		}
+		category := t.Category.String()
+		if t.Declaration {
+			category += "*"
+		}
+		result = append(result, category)
+	}
+	return strings.Join(result, " ")
+}
+
+func TestClassify(t *testing.T) {
+	tokens := classify()
+	offset := 0
+	for _, token := range tokens {
+		if token.Offset != offset {
+			t.Fatalf("expected a token at %d, got %d", offset, token.Offset)
+		}
+		offset += token.Length
+	}
+	if offset != len(source) {
+		t.Fatalf("expected the tokens to cover the source, ended at %d", offset)
+	}
+
+	for text, expected := range map[string]string{
+		"namespace":  "Keyword",
+		"<?php\n":    "Keyword",
+		"App":        "Namespace",
+		"Models":     "Namespace",
+		"Foo":        "Namespace Namespace DocType Namespace",
+		"Bar":        "ClassName DocType ClassName ClassName ClassName ClassName ClassName ClassName",
+		"helper":     "Function Function",
+		"Baz":        "DocType ClassName",
+		"@param":     "DocTag",
+		"@see":       "DocTag",
+		"f":          "Function*",
+		"int":        "Keyword",
+		"string":     "Keyword Keyword",
+		"$x":         "Variable Parameter* Parameter Parameter",
+		"$y":         "Parameter* Parameter",
+		"$rest":      "Parameter*",
+		"$local":     "Variable Variable",
+		"list":       "DocType Method Method*",
+		"prop":       "Property",
+		"CONST_A":    "Constant",
+		"create":     "Method",
+		"$shared":    "Property",
+		"name":       "Parameter",
+		"PHP_EOL":    "Constant",
+		"'yes'":      "String",
+		"instanceof": "Keyword",
+		"User":       "ClassName*",
+		"LIMIT":      "Constant*",
+		"10":         "Number",
+		"$name":      "Property*",
+		"+":          "Operator Operator",
+		";":          "None None None None None None None None None None",
+		"// done":    "Comment",
+	} {
+		if actual := categories(tokens, text); actual != expected {
+			t.Errorf("%s: expected %q, got %q", text, expected, actual)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	src := "<?php\n/* a\nb */ $x = 1;\n"
+	tokens := Classify(parser.Parse([]byte(src)), []byte(src))
+	data := Encode(tokens, position.NewIndex([]byte(src)))
+	expected := []uint32{
+		0, 0, 5, 0, 0, // <?php
+		1, 0, 4, 10, 0, // /* a
+		1, 0, 4, 10, 0, // b */
+		0, 5, 2, 7, 0, // $x
+		0, 3, 1, 13, 0, // =
+		0, 2, 1, 9, 0, // 1
+	}
+	if !equal(data, expected) {
+		t.Errorf("expected %v, got %v", expected, data)
+	}
+
+	src = "<?php const A = 1;"
+	tokens = Classify(parser.Parse([]byte(src)), []byte(src))
+	data = Encode(tokens, position.NewIndex([]byte(src)))
+	if len(data) < 15 || data[13] != 7 || data[14] != modifierDeclaration|modifierReadonly {
+		t.Errorf("expected a readonly constant declaration, got %v", data)
+	}
+}
+
+func equal(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRender(t *testing.T) {
+	src := "<?php echo '<b>';"
+	tokens := Classify(parser.Parse([]byte(src)), []byte(src))
+
+	var b bytes.Buffer
+	if err := RenderHTML(&b, []byte(src), tokens); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<span class="keyword">&lt;?php </span><span class="keyword">echo</span> ` +
+		`<span class="string">&#39;&lt;b&gt;&#39;</span>;`
+	if b.String() != expected {
+		t.Errorf("expected %s, got %s", expected, b.String())
+	}
+
+	b.Reset()
+	if err := RenderANSI(&b, []byte(src), tokens); err != nil {
+		t.Fatal(err)
+	}
+	expected = "\x1b[35m<?php \x1b[0m\x1b[35mecho\x1b[0m \x1b[32m'<b>'\x1b[0m;"
+	if b.String() != expected {
+		t.Errorf("expected %q, got %q", expected, b.String())
+	}
+}
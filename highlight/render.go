@@ -0,0 +1,75 @@
+package highlight
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"strings"
+)
+
+// ansiColours are the SGR parameters of each category
+var ansiColours = [...]string{
+	Keyword:   "35",
+	Namespace: "36",
+	ClassName: "33",
+	Function:  "34",
+	Method:    "34",
+	Property:  "36",
+	Parameter: "3",
+	Constant:  "1;36",
+	String:    "32",
+	Number:    "31",
+	Comment:   "90",
+	DocTag:    "1;90",
+	DocType:   "4;90",
+	Operator:  "37",
+}
+
+// RenderHTML writes source as HTML, each classified token wrapped in a span
+// whose class is its category in lower case, e.g. <span class="keyword">.
+// Text not covered by tokens is escaped and written as is.
+func RenderHTML(w io.Writer, source []byte, tokens []Token) error {
+	return render(w, source, tokens, html.EscapeString, func(category Category) (string, string) {
+		return `<span class="` + strings.ToLower(category.String()) + `">`, "</span>"
+	})
+}
+
+// RenderANSI writes source with ANSI escape sequences colouring the tokens
+func RenderANSI(w io.Writer, source []byte, tokens []Token) error {
+	return render(w, source, tokens, nil, func(category Category) (string, string) {
+		if ansiColours[category] == "" {
+			return "", ""
+		}
+		return "\x1b[" + ansiColours[category] + "m", "\x1b[0m"
+	})
+}
+
+func render(w io.Writer, source []byte, tokens []Token, escape func(string) string,
+	wrap func(Category) (string, string)) error {
+	b := bufio.NewWriter(w)
+	write := func(text []byte) {
+		if escape != nil {
+			b.WriteString(escape(string(text)))
+		} else {
+			b.Write(text)
+		}
+	}
+
+	offset := 0
+	for _, t := range tokens {
+		if t.Offset < offset {
+			continue
+		}
+		write(source[offset:t.Offset])
+		open, close := "", ""
+		if t.Category != None {
+			open, close = wrap(t.Category)
+		}
+		b.WriteString(open)
+		write(source[t.Offset : t.Offset+t.Length])
+		b.WriteString(close)
+		offset = t.Offset + t.Length
+	}
+	write(source[offset:])
+	return b.Flush()
+}
@@ -0,0 +1,100 @@
+package highlight
+
+import "github.com/john-nguyen09/go-phpparser/position"
+
+// TokenTypes and TokenModifiers are the legend of the LSP semantic token
+// encoding; a type or modifier is referred to by its index
+var (
+	TokenTypes = []string{
+		"keyword",
+		"namespace",
+		"class",
+		"function",
+		"method",
+		"property",
+		"parameter",
+		"variable",
+		"string",
+		"number",
+		"comment",
+		"decorator",
+		"type",
+		"operator",
+	}
+	TokenModifiers = []string{
+		"declaration",
+		"readonly",
+	}
+)
+
+const (
+	modifierDeclaration = 1 << iota
+	modifierReadonly
+)
+
+// semanticType is the index in TokenTypes of each category, constants being
+// readonly variables
+var semanticType = [...]int{
+	None:      -1,
+	Keyword:   0,
+	Namespace: 1,
+	ClassName: 2,
+	Function:  3,
+	Method:    4,
+	Property:  5,
+	Parameter: 6,
+	Variable:  7,
+	Constant:  7,
+	String:    8,
+	Number:    9,
+	Comment:   10,
+	DocTag:    11,
+	DocType:   12,
+	Operator:  13,
+}
+
+// Encode encodes tokens in the LSP relative format, five integers per token,
+// splitting tokens that span several lines. Tokens of category None are
+// left out.
+func Encode(tokens []Token, index *position.Index) []uint32 {
+	data := []uint32{}
+	prev := position.Position{}
+	for _, t := range tokens {
+		tokenType := semanticType[t.Category]
+		if tokenType < 0 {
+			continue
+		}
+		modifiers := 0
+		if t.Declaration {
+			modifiers |= modifierDeclaration
+		}
+		if t.Category == Constant {
+			modifiers |= modifierReadonly
+		}
+
+		start, end := t.Offset, t.Offset+t.Length
+		for start < end {
+			line := index.Line(start)
+			lineEnd := end
+			if next := index.LineStart(line + 1); next < lineEnd {
+				lineEnd = next
+			}
+			from, to := index.Position(start), index.Position(lineEnd)
+			if to.Line != from.Line {
+				// the piece ends with the line break
+				to = index.Position(index.Offset(position.Position{Line: from.Line, Character: 1 << 30}))
+			}
+			if length := to.Character - from.Character; length > 0 {
+				deltaStart := from.Character
+				if from.Line == prev.Line {
+					deltaStart -= prev.Character
+				}
+				data = append(data, uint32(from.Line-prev.Line), uint32(deltaStart),
+					uint32(length), uint32(tokenType), uint32(modifiers))
+				prev = from
+			}
+			start = lineEnd
+		}
+	}
+	return data
+}
@@ -0,0 +1,246 @@
+// Package jsontree converts phrase trees to and from JSON.
+//
+// A document is an object {"version": 1, "root": node} and a node is an object
+// with these members:
+//
+//	kind      "phrase", "token" or "error", a phrase.ParseError
+//	type      the PhraseType of phrases and errors or the TokenType of tokens
+//	range     {"start": point, "end": point}, where a point is
+//	          {"offset": bytes, "line": zero based, "character": UTF-16 units}
+//	text      the source text of a token, with Options.Text
+//	children  the children of phrases and errors, omitted when empty
+//	error     for errors, {"unexpected": token node or null, "expected": TokenType}
+//
+// Every token of the tree is exported, whitespace and comments included, so
+// that Unmarshal rebuilds the same tree. schema.json is the JSON Schema of
+// documents.
+package jsontree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+	"github.com/john-nguyen09/go-phpparser/position"
+)
+
+// Version is the version of the format written by Marshal
+const Version = 1
+
+const (
+	KindPhrase = "phrase"
+	KindToken  = "token"
+	KindError  = "error"
+)
+
+type Document struct {
+	Version int   `json:"version"`
+	Root    *Node `json:"root"`
+}
+
+type Node struct {
+	Kind     string  `json:"kind"`
+	Type     string  `json:"type"`
+	Range    Range   `json:"range"`
+	Text     string  `json:"text,omitempty"`
+	Children []*Node `json:"children,omitempty"`
+	Error    *Error  `json:"error,omitempty"`
+}
+
+type Range struct {
+	Start Point `json:"start"`
+	End   Point `json:"end"`
+}
+
+type Point struct {
+	Offset    int `json:"offset"`
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Error struct {
+	Unexpected *Node  `json:"unexpected"`
+	Expected   string `json:"expected"`
+}
+
+type Options struct {
+	// Text includes the source text of tokens
+	Text bool
+}
+
+var ErrVersion = errors.New("jsontree: unsupported version")
+
+// Marshal returns the JSON document of a tree parsed from source
+func Marshal(root *phrase.Phrase, source []byte, opts Options) ([]byte, error) {
+	return json.Marshal(Export(root, source, opts))
+}
+
+// Export returns the document of a tree parsed from source
+func Export(root *phrase.Phrase, source []byte, opts Options) *Document {
+	e := &exporter{source: source, index: position.NewIndex(source), opts: opts}
+	return &Document{Version: Version, Root: e.node(root)}
+}
+
+type exporter struct {
+	source []byte
+	index  *position.Index
+	opts   Options
+	// offset is the end of the last exported token, where empty phrases are
+	offset int
+}
+
+func (e *exporter) node(node phrase.AstNode) *Node {
+	switch node := node.(type) {
+	case *lexer.Token:
+		n := e.token(node)
+		e.offset = node.Offset + node.Length
+		return n
+	case *phrase.Phrase:
+		return e.phrase(KindPhrase, node)
+	case *phrase.ParseError:
+		n := e.phrase(KindError, &node.Phrase)
+		n.Error = &Error{Expected: node.Expected.String()}
+		if node.Unexpected != nil {
+			n.Error.Unexpected = e.token(node.Unexpected)
+		}
+		return n
+	}
+	return nil
+}
+
+func (e *exporter) token(t *lexer.Token) *Node {
+	n := &Node{Kind: KindToken, Type: t.Type.String(), Range: e.rangeOf(t.Offset, t.Offset+t.Length)}
+	if e.opts.Text {
+		n.Text = string(e.source[t.Offset : t.Offset+t.Length])
+	}
+	return n
+}
+
+func (e *exporter) phrase(kind string, p *phrase.Phrase) *Node {
+	n := &Node{Kind: kind, Type: p.Type.String()}
+	start := e.offset
+	for _, child := range p.Children {
+		n.Children = append(n.Children, e.node(child))
+	}
+	if len(n.Children) > 0 {
+		start = n.Children[0].Range.Start.Offset
+	}
+	n.Range = e.rangeOf(start, e.offset)
+	return n
+}
+
+func (e *exporter) rangeOf(start, end int) Range {
+	return Range{e.point(start), e.point(end)}
+}
+
+func (e *exporter) point(offset int) Point {
+	p := e.index.Position(offset)
+	return Point{Offset: offset, Line: p.Line, Character: p.Character}
+}
+
+// Unmarshal rebuilds a tree from a JSON document. Only the offsets of ranges
+// are used.
+func Unmarshal(data []byte) (*phrase.Phrase, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc.Tree()
+}
+
+// Tree rebuilds the tree of a document
+func (doc *Document) Tree() (*phrase.Phrase, error) {
+	if doc.Version != Version {
+		return nil, ErrVersion
+	}
+	if doc.Root == nil || doc.Root.Kind != KindPhrase {
+		return nil, errors.New("jsontree: the root must be a phrase")
+	}
+	i := &importer{tokens: map[lexer.Token]*lexer.Token{}}
+	root, err := i.node(doc.Root)
+	if err != nil {
+		return nil, err
+	}
+	// the unexpected token of an error is usually a token of the tree
+	for _, err := range i.errors {
+		if t, ok := i.tokens[*err.Unexpected]; ok {
+			err.Unexpected = t
+		}
+	}
+	return root.(*phrase.Phrase), nil
+}
+
+type importer struct {
+	tokens map[lexer.Token]*lexer.Token
+	errors []*phrase.ParseError
+}
+
+func (i *importer) node(n *Node) (phrase.AstNode, error) {
+	if n == nil {
+		return nil, errors.New("jsontree: null node")
+	}
+	switch n.Kind {
+	case KindToken:
+		t, err := i.token(n)
+		if err != nil {
+			return nil, err
+		}
+		i.tokens[*t] = t
+		return t, nil
+	case KindPhrase, KindError:
+		phraseType, ok := phrase.PhraseTypeByName(n.Type)
+		if !ok {
+			return nil, fmt.Errorf("jsontree: unknown phrase type %q", n.Type)
+		}
+		// like the parser, phrases without children have an empty slice
+		p := &phrase.Phrase{Type: phraseType, Children: make([]phrase.AstNode, 0, len(n.Children))}
+		for _, child := range n.Children {
+			c, err := i.node(child)
+			if err != nil {
+				return nil, err
+			}
+			p.Children = append(p.Children, c)
+		}
+		if n.Kind == KindPhrase {
+			return p, nil
+		}
+		if n.Error == nil {
+			return nil, errors.New("jsontree: error node without error details")
+		}
+		expected, ok := lexer.TokenTypeByName(n.Error.Expected)
+		if !ok {
+			return nil, fmt.Errorf("jsontree: unknown token type %q", n.Error.Expected)
+		}
+		err := &phrase.ParseError{Phrase: *p, Expected: expected}
+		if n.Error.Unexpected != nil {
+			t, e := i.token(n.Error.Unexpected)
+			if e != nil {
+				return nil, e
+			}
+			err.Unexpected = t
+			i.errors = append(i.errors, err)
+		}
+		return err, nil
+	}
+	return nil, fmt.Errorf("jsontree: unknown node kind %q", n.Kind)
+}
+
+func (i *importer) token(n *Node) (*lexer.Token, error) {
+	if n.Kind != KindToken {
+		return nil, fmt.Errorf("jsontree: expected a token, got %q", n.Kind)
+	}
+	if len(n.Children) > 0 {
+		return nil, errors.New("jsontree: token with children")
+	}
+	tokenType, ok := lexer.TokenTypeByName(n.Type)
+	if !ok {
+		return nil, fmt.Errorf("jsontree: unknown token type %q", n.Type)
+	}
+	start, end := n.Range.Start.Offset, n.Range.End.Offset
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("jsontree: invalid token range %d-%d", start, end)
+	}
+	return &lexer.Token{Type: tokenType, Offset: start, Length: end - start}, nil
+}
@@ -0,0 +1,129 @@
+package jsontree
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+func TestMarshal(t *testing.T) {
+	source := []byte("<?php\n$a = ;")
+	data, err := Marshal(parser.Parse(source), source, Options{Text: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	root := doc.Root
+	if doc.Version != Version || root.Kind != KindPhrase || root.Type != "StatementList" ||
+		root.Range.End.Offset != len(source) {
+		t.Fatalf("unexpected root %+v", root)
+	}
+	open := root.Children[0].Children[0]
+	if open.Kind != KindToken || open.Type != "OpenTag" || open.Text != "<?php\n" ||
+		open.Range.End != (Point{Offset: 6, Line: 1, Character: 0}) {
+		t.Errorf("unexpected open tag %+v", open)
+	}
+
+	var errorNode *Node
+	var find func(n *Node)
+	find = func(n *Node) {
+		if n.Kind == KindError {
+			errorNode = n
+		}
+		for _, child := range n.Children {
+			find(child)
+		}
+	}
+	find(root)
+	if errorNode == nil || errorNode.Error.Expected == "" || errorNode.Error.Unexpected == nil ||
+		errorNode.Error.Unexpected.Text != ";" {
+		t.Errorf("expected an error node with the unexpected token, got %+v", errorNode)
+	}
+
+	data, _ = Marshal(parser.Parse(source), source, Options{})
+	if strings.Contains(string(data), `"text"`) {
+		t.Errorf("expected no text without Options.Text")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	paths, _ := filepath.Glob("../cases/*.php")
+	if len(paths) == 0 {
+		t.Fatal("no cases")
+	}
+	for _, path := range paths {
+		source, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := parser.Parse(source)
+		data, err := Marshal(expected, source, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := Unmarshal(data)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: the tree differs after a round trip", path)
+		}
+		checkUnexpected(t, path, actual)
+	}
+}
+
+// checkUnexpected checks that the unexpected token of errors is shared with
+// the tree when it is part of it
+func checkUnexpected(t *testing.T, path string, root *phrase.Phrase) {
+	tokens := map[lexer.Token]*lexer.Token{}
+	var errors []*phrase.ParseError
+	var walk func(node phrase.AstNode)
+	walk = func(node phrase.AstNode) {
+		switch node := node.(type) {
+		case *phrase.Phrase:
+			for _, child := range node.Children {
+				walk(child)
+			}
+		case *phrase.ParseError:
+			errors = append(errors, node)
+			for _, child := range node.Children {
+				walk(child)
+			}
+		case *lexer.Token:
+			tokens[*node] = node
+		}
+	}
+	walk(root)
+	for _, err := range errors {
+		if token, ok := tokens[*err.Unexpected]; ok && token != err.Unexpected {
+			t.Errorf("%s: the unexpected token at %d is a copy", path, err.Unexpected.Offset)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for _, data := range []string{
+		`{"version": 2, "root": {"kind": "phrase", "type": "StatementList"}}`,
+		`{"version": 1, "root": {"kind": "token", "type": "Name"}}`,
+		`{"version": 1, "root": {"kind": "phrase", "type": "Nope"}}`,
+		`{"version": 1, "root": {"kind": "phrase", "type": "StatementList", "children": [{"kind": "what"}]}}`,
+		`{"version": 1, "root": {"kind": "phrase", "type": "StatementList", "children": [{"kind": "error", "type": "Error"}]}}`,
+		`{"version": 1, "root": {"kind": "phrase", "type": "StatementList", "children": [
+			{"kind": "token", "type": "Name", "range": {"start": {"offset": 4}, "end": {"offset": 2}}}]}}`,
+	} {
+		if _, err := Unmarshal([]byte(data)); err == nil {
+			t.Errorf("expected an error for %s", data)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package lexer
+
+// DiagnosticKind is the kind of a lexical error or warning
+type DiagnosticKind uint8
+
+const (
+	UnterminatedComment DiagnosticKind = iota
+	UnterminatedString
+	UnterminatedBacktick
+	UnterminatedHeredoc
+	// ByteOrderMark is a UTF-8 byte order mark at the start of the source,
+	// which PHP outputs as text
+	ByteOrderMark
+)
+
+var /* const */ diagnosticKindStrings = []string{
+	"UnterminatedComment",
+	"UnterminatedString",
+	"UnterminatedBacktick",
+	"UnterminatedHeredoc",
+	"ByteOrderMark",
+}
+
+var /* const */ diagnosticMessages = []string{
+	"Unterminated comment",
+	"Unterminated string",
+	"Unterminated backtick string",
+	"Unterminated heredoc",
+	"Byte order mark is output before headers",
+}
+
+func (kind DiagnosticKind) String() string {
+	if int(kind) >= len(diagnosticKindStrings) {
+		return "Unknown"
+	}
+	return diagnosticKindStrings[int(kind)]
+}
+
+// IsWarning reports whether diagnostics of the kind are warnings, which
+// leave the source as PHP lexes it, rather than errors
+func (kind DiagnosticKind) IsWarning() bool {
+	return kind == ByteOrderMark
+}
+
+// Diagnostic is a lexical error, such as a comment which starts at Offset and
+// is not closed before the end of the source, Length bytes later
+type Diagnostic struct {
+	Kind   DiagnosticKind
+	Offset int
+	Length int
+}
+
+// Message describes the diagnostic
+func (d Diagnostic) Message() string {
+	if int(d.Kind) >= len(diagnosticMessages) {
+		return "Unknown error"
+	}
+	return diagnosticMessages[int(d.Kind)]
+}
+
+// Diagnostics returns the lexical errors found so far. Strings and doc blocks
+// which are still open are reported once the end of the source is lexed.
+func (s *Lexer) Diagnostics() []Diagnostic {
+	return s.diagnostics
+}
+
+// open records the start of a string or doc block spanning several tokens
+func (s *Lexer) open(kind DiagnosticKind, start int) {
+	s.opened = append(s.opened, Diagnostic{Kind: kind, Offset: start})
+}
+
+// close forgets the innermost string or doc block opened. Lexers started in
+// the middle of one have nothing to forget.
+func (s *Lexer) close() {
+	if len(s.opened) > 0 {
+		s.opened = s.opened[:len(s.opened)-1]
+	}
+}
+
+// unterminated reports a construct from start to the end of the source
+func (s *Lexer) unterminated(kind DiagnosticKind, start int) {
+	s.diagnostics = append(s.diagnostics, Diagnostic{kind, start, s.offset - start})
+}
+
+// endOfFile reports the strings and doc blocks left open
+func (s *Lexer) endOfFile() {
+	for _, d := range s.opened {
+		s.unterminated(d.Kind, d.Offset)
+	}
+	s.opened = s.opened[:0]
+}
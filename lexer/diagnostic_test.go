@@ -0,0 +1,54 @@
+package lexer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected []Diagnostic
+	}{
+		{"<?php /* a */ /** @var int */ 'b' \"c $d\" `e` <<<F\ng\nF;\n<<<'H'\ni\nH;\n", nil},
+		{"<?php $a; /* b", []Diagnostic{{UnterminatedComment, 10, 4}}},
+		{"\xEF\xBB\xBF<?php $a;", []Diagnostic{{ByteOrderMark, 0, 3}}},
+		{"\xEF\xBB\xBFa\xEF\xBB\xBF<?php /*", []Diagnostic{{ByteOrderMark, 0, 3}, {UnterminatedComment, 13, 2}}},
+		{"<?php $a; /** @var int", []Diagnostic{{UnterminatedComment, 10, 12}}},
+		{"<?php /** @a <", []Diagnostic{{UnterminatedComment, 6, 8}}},
+		{"<?php $a = 'b\\'", []Diagnostic{{UnterminatedString, 11, 4}}},
+		{"<?php $a = \"b\\\"", []Diagnostic{{UnterminatedString, 11, 4}}},
+		{"<?php $a = \"b $c", []Diagnostic{{UnterminatedString, 11, 5}}},
+		{"<?php $a = \"b {$c . \"d\" } e", []Diagnostic{{UnterminatedString, 11, 16}}},
+		{"<?php $a = `ls", []Diagnostic{{UnterminatedBacktick, 11, 3}}},
+		{"<?php $a = <<<EOT\nb\nEO", []Diagnostic{{UnterminatedHeredoc, 11, 11}}},
+		{"<?php $a = <<<'EOT'\nb\nEOT", nil},
+		{"<?php $a = <<<EOT\nb\nEOT", nil},
+		{"<?php $a = <<<'EOT'\nb\nEOTX\n", []Diagnostic{{UnterminatedHeredoc, 11, 16}}},
+		{"<?php $a = `b {$c . \"d /* e", []Diagnostic{
+			{UnterminatedBacktick, 11, 16},
+			{UnterminatedString, 20, 7},
+		}},
+	}
+
+	for _, test := range tests {
+		l := NewLexer([]byte(test.source), nil, 0)
+		lexAll(l)
+		if !equalDiagnostics(l.Diagnostics(), test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.source, test.expected, l.Diagnostics())
+		}
+
+		// lexing a token again with more bytes must not report twice
+		l = NewReaderLexer(iotest.OneByteReader(bytes.NewReader([]byte(test.source))), nil, 1)
+		lexAll(l)
+		if !equalDiagnostics(l.Diagnostics(), test.expected) {
+			t.Errorf("%q from a reader: expected %v, got %v", test.source, test.expected, l.Diagnostics())
+		}
+	}
+}
+
+func equalDiagnostics(a []Diagnostic, b []Diagnostic) bool {
+	return len(a) == len(b) && (len(a) == 0 || reflect.DeepEqual(a, b))
+}
@@ -23,8 +23,14 @@ func (s *Lexer) scriptingDocBlock() *Token {
 		if s.peek(1) == '/' {
 			s.stepLoop(2)
 			s.modeStack = s.modeStack[:len(s.modeStack)-1]
+			s.close()
 			return NewToken(s.pool, DocumentCommentEnd, start, s.offset-start)
 		}
+		if !s.isDocBlockLineStart() {
+			// Foo::*, Foo::BAR_* and the wildcard in Foo<*>
+			s.step()
+			return NewToken(s.pool, Asterisk, start, s.offset-start)
+		}
 		for s.step(); isWhitespace(s.r) || s.r == '*'; s.step() {
 			if s.r == '*' && s.peek(1) == '/' {
 				break
@@ -71,6 +77,52 @@ func (s *Lexer) scriptingDocumentBlockLabel() *Token {
 	if c == ',' {
 		return NewToken(s.pool, Comma, start, s.offset-start)
 	}
+	if c == '[' {
+		return NewToken(s.pool, OpenBracket, start, s.offset-start)
+	}
+	if c == ']' {
+		return NewToken(s.pool, CloseBracket, start, s.offset-start)
+	}
+	if c == '{' {
+		return NewToken(s.pool, OpenBrace, start, s.offset-start)
+	}
+	if c == '}' {
+		return NewToken(s.pool, CloseBrace, start, s.offset-start)
+	}
+	if c == '`' {
+		return NewToken(s.pool, Backtick, start, s.offset-start)
+	}
+	if c == '?' {
+		return NewToken(s.pool, Question, start, s.offset-start)
+	}
+	if c == '&' {
+		return NewToken(s.pool, Ampersand, start, s.offset-start)
+	}
+	if c == ':' {
+		if s.r == ':' {
+			s.step()
+			return NewToken(s.pool, ColonColon, start, s.offset-start)
+		}
+		return NewToken(s.pool, Colon, start, s.offset-start)
+	}
+	if c == '!' && isLabelStart(s.r) {
+		// negated assertion, e.g. @psalm-assert !null $x
+		return NewToken(s.pool, Exclamation, start, s.offset-start)
+	}
+	if c == '-' && s.r == '>' && isLabelStart(s.peek(1)) {
+		s.step()
+		return NewToken(s.pool, Arrow, start, s.offset-start)
+	}
+	if c == '.' && s.r == '.' && s.peek(1) == '.' {
+		s.stepLoop(2)
+		return NewToken(s.pool, Ellipsis, start, s.offset-start)
+	}
+	if c == '\'' || c == '"' {
+		if n := s.docBlockStringLength(c); n > 0 {
+			s.stepLoop(n)
+			return NewToken(s.pool, StringLiteral, start, s.offset-start)
+		}
+	}
 	if (c == 's' || c == 'S') &&
 		(s.r == 't' || s.r == 'T') &&
 		strings.ToLower(s.peekSpanString(0, 4)) == "atic" &&
@@ -78,7 +130,7 @@ func (s *Lexer) scriptingDocumentBlockLabel() *Token {
 		s.stepLoop(5)
 		return NewToken(s.pool, Static, start, s.offset-start)
 	}
-	if isDigit(c) {
+	if isDigit(c) || (c == '-' && isDigit(s.r)) {
 		tokenType := IntegerLiteral
 		for isDigit(s.r) || s.r == '.' {
 			if s.r == '.' {
@@ -92,13 +144,14 @@ func (s *Lexer) scriptingDocumentBlockLabel() *Token {
 		return NewToken(s.pool, Dollar, start, s.offset-start)
 	}
 	if isLabelStart(c) {
-		for ; isLabelChar(s.r); s.step() {
+		// PHPDoc type keywords such as class-string and non-empty-array
+		for ; isLabelChar(s.r) || (s.r == '-' && isLabelStart(s.peek(1))); s.step() {
 		}
 		return NewToken(s.pool, Name, start, s.offset-start)
 	}
 	if isDocCommentText(c, s.r) {
-		for ; isDocCommentText(s.r, s.peek(1)) && s.r != '[' && s.r != '|' &&
-			s.r != '/' && s.r != '\\' &&
+		for ; isDocCommentText(s.r, s.peek(1)) && s.r != '[' && s.r != ']' && s.r != '|' &&
+			s.r != '/' && s.r != '\\' && s.r != '{' && s.r != '}' && s.r != '`' &&
 			s.r != '<' && s.r != '>' && s.r != '(' && s.r != ')'; s.step() {
 		}
 		return NewToken(s.pool, DocumentCommentText, start, s.offset-start)
@@ -112,40 +165,116 @@ func (s *Lexer) docBlockTagName() *Token {
 	start := s.offset
 	startLabel := 1
 	endLabel := startLabel
-	for ; !isWhitespace(s.peek(endLabel)); endLabel++ {
+	// the closing brace of an inline tag such as {@inheritDoc} is not part of the name
+	for c := s.peek(endLabel); !isWhitespace(c) && c != '}' && c != -1 &&
+		!(c == '*' && s.peek(endLabel+1) == '/'); c = s.peek(endLabel) {
+		endLabel++
 	}
 	tagName := s.peekSpanString(startLabel-1, endLabel-1)
-	tokenType := DocumentCommentTagName
+	tokenType := docBlockTagType(tagName)
+	if tokenType == DocumentCommentTagName {
+		// @psalm-param, @phpstan-return and friends are aliases of the plain tags
+		for _, prefix := range []string{"psalm-", "phpstan-"} {
+			if strings.HasPrefix(tagName, prefix) {
+				tokenType = docBlockTagType(tagName[len(prefix):])
+				break
+			}
+		}
+	}
+	s.stepLoop(endLabel)
+	return NewToken(s.pool, tokenType, start, s.offset-start)
+}
+
+func docBlockTagType(tagName string) TokenType {
 	switch tagName {
 	case "author":
-		tokenType = AtAuthor
+		return AtAuthor
 	case "deprecated":
-		tokenType = AtDeprecated
+		return AtDeprecated
 	case "global":
-		tokenType = AtGlobal
+		return AtGlobal
 	case "link":
-		tokenType = AtLink
+		return AtLink
 	case "method":
-		tokenType = AtMethod
+		return AtMethod
 	case "param":
-		tokenType = AtParam
+		return AtParam
 	case "property":
-		tokenType = AtProperty
+		return AtProperty
 	case "property-read":
-		tokenType = AtPropertyRead
+		return AtPropertyRead
 	case "property-write":
-		tokenType = AtPropertyWrite
+		return AtPropertyWrite
 	case "return":
-		tokenType = AtReturn
+		return AtReturn
 	case "since":
-		tokenType = AtSince
+		return AtSince
 	case "throws":
-		tokenType = AtThrows
+		return AtThrows
 	case "var":
-		tokenType = AtVar
+		return AtVar
+	case "template":
+		return AtTemplate
+	case "template-covariant":
+		return AtTemplateCovariant
+	case "template-contravariant":
+		return AtTemplateContravariant
+	case "extends", "template-extends":
+		return AtExtends
+	case "implements", "template-implements":
+		return AtImplements
+	case "use", "template-use":
+		return AtUse
+	case "mixin":
+		return AtMixin
+	case "param-out":
+		return AtParamOut
+	case "assert":
+		return AtAssert
+	case "assert-if-true":
+		return AtAssertIfTrue
+	case "assert-if-false":
+		return AtAssertIfFalse
+	case "see":
+		return AtSee
+	case "inheritDoc", "inheritdoc":
+		return AtInheritDoc
+	}
+	return DocumentCommentTagName
+}
+
+// isDocBlockLineStart reports whether the * under the cursor is the leading
+// asterisk of a line rather than part of a type such as Foo::*
+func (s *Lexer) isDocBlockLineStart() bool {
+	for k := -1; ; k-- {
+		switch s.peek(k) {
+		case ' ', '\t':
+			continue
+		case '\n', '\r', '*', -1:
+			return true
+		}
+		return false
+	}
+}
+
+// docBlockStringLength returns the length of a quoted literal type such as 'foo',
+// counted from the character after the opening quote, or 0 if the quote is not
+// closed on the same line
+func (s *Lexer) docBlockStringLength(quote rune) int {
+	for n := 0; ; n++ {
+		switch c := s.peek(n); c {
+		case quote:
+			return n + 1
+		case '\\':
+			n++
+		case '\r', '\n', -1:
+			return 0
+		case '*':
+			if s.peek(n+1) == '/' {
+				return 0
+			}
+		}
 	}
-	s.stepLoop(endLabel)
-	return NewToken(s.pool, tokenType, start, s.offset-start)
 }
 
 func isDocCommentText(cp, next rune) bool {
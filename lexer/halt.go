@@ -0,0 +1,64 @@
+package lexer
+
+// the progress through __halt_compiler ( ) ; after which the rest of the
+// source is data rather than PHP
+const (
+	haltNone = iota
+	haltKeyword
+	haltOpenParenthesis
+	haltCloseParenthesis
+	// haltCloseTag is __halt_compiler() ?>, whose data starts after the
+	// newline following the close tag
+	haltCloseTag
+)
+
+// haltCompiler follows the tokens of __halt_compiler(); and switches to
+// ModeHaltCompilerData after it. __halt_compiler after ::, -> and function
+// is a method name.
+func (s *Lexer) haltCompiler(t *Token) {
+	if t.Type == Comment || t.Type == Whitespace {
+		return
+	}
+	switch {
+	case t.Type == HaltCompiler:
+		s.halt = haltNone
+		if !s.member {
+			s.halt = haltKeyword
+		}
+	case s.halt == haltKeyword && t.Type == OpenParenthesis:
+		s.halt = haltOpenParenthesis
+	case s.halt == haltOpenParenthesis && t.Type == CloseParenthesis:
+		s.halt = haltCloseParenthesis
+	case s.halt == haltCloseParenthesis && t.Type == Semicolon:
+		s.halt = haltNone
+		s.modeStack[len(s.modeStack)-1] = ModeHaltCompilerData
+	case s.halt == haltCloseParenthesis && t.Type == CloseTag:
+		s.halt = haltCloseTag
+		s.modeStack[len(s.modeStack)-1] = ModeHaltCompilerData
+	default:
+		s.halt = haltNone
+	}
+}
+
+// haltCompilerData returns the rest of the source as a HaltCompilerData
+// token, which starts where __COMPILER_HALT_OFFSET__ points and is empty at
+// the end of the source. The newline after a close tag, which PHP takes as
+// part of the tag, comes first as whitespace.
+func (s *Lexer) haltCompilerData() *Token {
+	start := s.offset
+	if s.halt == haltCloseTag {
+		s.halt = haltNone
+		if s.r == '\r' && s.peek(1) == '\n' {
+			s.stepLoop(2)
+			return NewToken(s.pool, Whitespace, start, 2)
+		}
+		if s.r == '\n' || s.r == '\r' {
+			s.step()
+			return NewToken(s.pool, Whitespace, start, 1)
+		}
+	}
+	s.nextOffset = s.sourceEnd()
+	s.step()
+	s.modeStack[len(s.modeStack)-1] = ModeScripting
+	return NewToken(s.pool, HaltCompilerData, start, s.offset-start)
+}
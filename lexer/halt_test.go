@@ -0,0 +1,62 @@
+package lexer
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHaltCompiler(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected []string
+	}{
+		{"<?php __halt_compiler();<?php $a", []string{"OpenTag <?php ", "HaltCompiler __halt_compiler",
+			"OpenParenthesis (", "CloseParenthesis )", "Semicolon ;", "HaltCompilerData <?php $a"}},
+		{"<?php __HALT_COMPILER ( /**/ ) ;", []string{"OpenTag <?php ", "HaltCompiler __HALT_COMPILER",
+			"Whitespace  ", "OpenParenthesis (", "Whitespace  ", "Comment /**/", "Whitespace  ",
+			"CloseParenthesis )", "Whitespace  ", "Semicolon ;", "HaltCompilerData "}},
+		{"<?php __halt_compiler() ?>\ndata", []string{"OpenTag <?php ", "HaltCompiler __halt_compiler",
+			"OpenParenthesis (", "CloseParenthesis )", "Whitespace  ", "CloseTag ?>", "Whitespace \n",
+			"HaltCompilerData data"}},
+		{"<?php __halt_compiler()?>\r\n\ndata", []string{"OpenTag <?php ", "HaltCompiler __halt_compiler",
+			"OpenParenthesis (", "CloseParenthesis )", "CloseTag ?>", "Whitespace \r\n", "HaltCompilerData \ndata"}},
+		{"<?php A::__halt_compiler(); $a->__halt_compiler(); $b", []string{"OpenTag <?php ", "Name A",
+			"ColonColon ::", "HaltCompiler __halt_compiler", "OpenParenthesis (", "CloseParenthesis )",
+			"Semicolon ;", "Whitespace  ", "VariableName $a", "Arrow ->", "Name __halt_compiler",
+			"OpenParenthesis (", "CloseParenthesis )", "Semicolon ;", "Whitespace  ", "VariableName $b"}},
+		{"<?php __halt_compiler; $a", []string{"OpenTag <?php ", "HaltCompiler __halt_compiler", "Semicolon ;",
+			"Whitespace  ", "VariableName $a"}},
+	}
+
+	for _, test := range tests {
+		actual := []string{}
+		for it := Tokens([]byte(test.source)); it.Next(); {
+			actual = append(actual, it.Token().Type.String()+" "+string(it.Text()))
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.source, test.expected, actual)
+		}
+	}
+
+	// the data is not hidden like comments and whitespace
+	it := Tokens([]byte("<?php __halt_compiler(); data")).SkipHidden()
+	for it.Next() && !it.Is(HaltCompilerData) {
+	}
+	if !it.Is(HaltCompilerData) || string(it.Text()) != " data" {
+		t.Errorf("expected the data when skipping hidden tokens, got %v", it.Token())
+	}
+}
+
+func TestReaderHaltCompiler(t *testing.T) {
+	source := []byte("<?php echo 1;\n__halt_compiler();" + string(bytes.Repeat([]byte("<?php 'x"), 1000)))
+	expected := lexAll(NewLexer(source, nil, 0))
+	actual := lexAll(NewReaderLexer(iotest.OneByteReader(bytes.NewReader(source)), nil, 64))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if data := expected[len(expected)-2]; data.Type != HaltCompilerData || data.Offset != 32 {
+		t.Errorf("unexpected data token %v", data)
+	}
+}
@@ -7,81 +7,102 @@ import (
 	"unicode/utf8"
 )
 
-func decodeAll(bs []byte) ([]rune, []uint8) {
-	runes := make([]rune, 0, len(bs))
-	sizes := make([]uint8, 0, len(bs))
-	lenBs := len(bs)
-	for i := 0; i < lenBs; {
-		c := bs[i]
-		var (
-			r    rune
-			size int
-		)
-		if c < utf8.RuneSelf {
-			r = rune(c)
-			size = 1
-		} else {
-			r, size = utf8.DecodeRune(bs[i:])
-		}
-		runes = append(runes, r)
-		sizes = append(sizes, uint8(size))
-		i += size
-	}
-	return runes, sizes
-}
-
+// Lexer scans the bytes of a source. Characters outside ASCII only occur in
+// labels, strings, comments and inline HTML and every byte of their UTF-8
+// encoding is at least utf8.RuneSelf, which isLabelStart and isLabelChar
+// accept, so they are scanned byte by byte without being decoded.
 type Lexer struct {
-	offset                   int
-	nextOffset               int
-	sourceBytes              []byte
-	source                   []rune
-	sourceSizes              []uint8
+	// offset is the offset of r and nextOffset the offset after it
+	offset     int
+	nextOffset int
+	// sourceBytes holds the source from base on, which is 0 unless reading
+	// from an io.Reader
+	base        int
+	sourceBytes []byte
+	reader      *sourceReader
+	// bufferEnd is set when lexing reaches the end of sourceBytes, after
+	// which a lexer reading from an io.Reader lexes the token again with
+	// more bytes
+	bufferEnd                bool
 	modeStack                []LexerMode
 	doubleQuoteScannedLength int
 	heredocLabel             string
 	r                        rune
 	pool                     *Pool
+	opts                     Options
+	// halt is the progress through __halt_compiler(); and member is set
+	// after ::, ->, function and const, which make it a name
+	halt   int
+	member bool
+	// opened are the strings and doc blocks being lexed, innermost last
+	opened      []Diagnostic
+	diagnostics []Diagnostic
 }
 
+// NewLexer returns a lexer of source from the byte offset on, in the modes of
+// modeStack or ModeInitial when it is nil. Lexing from the middle of a string
+// or heredoc needs more than its mode, see NewLexerFromState.
 func NewLexer(source []byte, modeStack []LexerMode, offset int) *Lexer {
+	return NewLexerWithPool(source, modeStack, offset, NewPool(DefaultBlockSize))
+}
+
+// NewLexerWithPool returns a lexer which allocates its tokens from pool
+func NewLexerWithPool(source []byte, modeStack []LexerMode, offset int, pool *Pool) *Lexer {
+	return newLexer(source, modeStack, offset, pool, nil)
+}
+
+func newLexer(source []byte, modeStack []LexerMode, offset int, pool *Pool, reader *sourceReader) *Lexer {
 	if modeStack == nil {
 		modeStack = []LexerMode{ModeInitial}
 	}
-	runes, sizes := decodeAll(source)
 	lexer := &Lexer{
 		offset:                   offset,
 		nextOffset:               offset,
 		sourceBytes:              source,
-		source:                   runes,
-		sourceSizes:              sizes,
+		reader:                   reader,
 		modeStack:                modeStack,
 		doubleQuoteScannedLength: -1,
 		heredocLabel:             "",
 		r:                        0,
-		pool:                     NewPool(DefaultBlockSize),
+		pool:                     pool,
+	}
+	if reader != nil {
+		reader.read(lexer)
 	}
 	lexer.step()
 	return lexer
 }
 
 func (s *Lexer) step() {
-	if s.nextOffset > len(s.source) {
+	if s.nextOffset > s.sourceEnd() {
 		return
 	}
-	if s.nextOffset == len(s.source) {
+	s.offset = s.nextOffset
+	s.nextOffset++
+	if s.offset == s.sourceEnd() {
 		s.r = -1
-		if s.nextOffset != 0 {
-			s.offset++
-		}
-		s.nextOffset++
+		s.bufferEnd = true
 		return
 	}
-	r := s.source[s.nextOffset]
-	s.r = r
-	if s.nextOffset > 0 {
-		s.offset += int(s.sourceSizes[s.nextOffset])
+	s.r = rune(s.sourceBytes[s.offset-s.base])
+}
+
+// sourceEnd returns the end of the bytes read, which is the end of the source
+// unless reading from an io.Reader
+func (s *Lexer) sourceEnd() int {
+	return s.base + len(s.sourceBytes)
+}
+
+// span returns the bytes from start to end, cut at the end of the bytes read
+func (s *Lexer) span(start int, end int) []byte {
+	if end > s.sourceEnd() {
+		end = s.sourceEnd()
+		s.bufferEnd = true
 	}
-	s.nextOffset++
+	if start > end {
+		start = end
+	}
+	return s.sourceBytes[start-s.base : end-s.base]
 }
 
 func (s *Lexer) stepLoop(n int) {
@@ -91,26 +112,30 @@ func (s *Lexer) stepLoop(n int) {
 }
 
 func (s *Lexer) peek(offset int) rune {
-	if s.nextOffset+offset-1 >= len(s.source) {
+	offset += s.nextOffset - 1 - s.base
+	if offset >= len(s.sourceBytes) {
+		s.bufferEnd = true
 		return -1
 	}
-	if s.nextOffset+offset-1 < 0 {
+	if offset < 0 {
 		return -1
 	}
-	c := s.source[s.nextOffset+offset-1]
-	return c
+	return rune(s.sourceBytes[offset])
 }
 
 func (s *Lexer) peekSpanString(offset int, n int) string {
 	offset += s.nextOffset
-	if offset >= len(s.source) {
+	if offset >= s.sourceEnd() {
+		s.bufferEnd = true
 		return ""
 	}
 	end := offset + n
-	if end >= len(s.source) {
-		end = len(s.source) - 1
+	if end >= s.sourceEnd() {
+		// one byte short of the end of the source
+		end = s.sourceEnd() - 1
+		s.bufferEnd = true
 	}
-	return string(s.source[offset:end])
+	return string(s.sourceBytes[offset-s.base : end-s.base])
 }
 
 // ModeStack returns a copy of modeStack
@@ -122,7 +147,15 @@ func (s *Lexer) ModeStack() []LexerMode {
 
 // Lex runs the lexing and returns a token
 func (s *Lexer) Lex() *Token {
-	if s.r == -1 {
+	if s.reader != nil {
+		return s.reader.lex(s)
+	}
+	return s.lex()
+}
+
+func (s *Lexer) lex() *Token {
+	if s.r == -1 && s.modeStack[len(s.modeStack)-1] != ModeHaltCompilerData {
+		s.endOfFile()
 		return NewToken(s.pool, EndOfFile, s.offset, 0)
 	}
 
@@ -151,10 +184,18 @@ func (s *Lexer) Lex() *Token {
 		t = s.lookingForVarName()
 	case ModeDocumentBlock:
 		t = s.scriptingDocBlock()
+	case ModeHaltCompilerData:
+		t = s.haltCompilerData()
 	}
 
 	if t == nil {
-		t = s.Lex()
+		return s.Lex()
+	}
+	if s.halt != haltNone || t.Type == HaltCompiler {
+		s.haltCompiler(t)
+	}
+	if t.Type != Comment && t.Type != Whitespace {
+		s.member = t.Type == ColonColon || t.Type == Arrow || t.Type == Function || t.Type == Const
 	}
 
 	return t
@@ -171,51 +212,17 @@ func (tokenType *TokenType) MarshalJSON() ([]byte, error) {
 
 func (s *Lexer) initial() *Token {
 	start := s.offset
-	c := s.r
-	if c == '<' && s.peek(1) == '?' {
-		if isWhitespace(s.peek(2)) {
-			if s.peek(2) == '\r' && s.peek(3) == '\n' {
-				s.stepLoop(4)
-			} else {
-				s.stepLoop(3)
-			}
-			token := NewToken(s.pool, OpenTag, start, s.offset-start)
-			s.modeStack[len(s.modeStack)-1] = ModeScripting
-			return token
-		}
-		if s.peek(2) == '=' &&
-			(s.peek(3) == -1 || isWhitespace(s.peek(3))) {
-			if s.peek(3) == '\r' && s.peek(4) == '\n' {
-				s.stepLoop(5)
-			} else {
-				s.stepLoop(4)
-			}
-
-			token := NewToken(s.pool, OpenTagEcho, start, s.offset-start)
-			s.modeStack[len(s.modeStack)-1] = ModeScripting
-			return token
-		}
-		if strings.ToLower(string(s.peekSpanString(0, 4))) == "?php" && isWhitespace(s.peek(5)) {
-			if s.peek(5) == '\r' && s.peek(6) == '\n' {
-				s.stepLoop(7)
-			} else {
-				s.stepLoop(6)
-			}
-			token := NewToken(s.pool, OpenTag, start, s.offset-start)
-			s.modeStack[len(s.modeStack)-1] = ModeScripting
-			return token
-		}
+	if start == 0 && s.r == 0xEF && s.peek(1) == 0xBB && s.peek(2) == 0xBF {
+		s.diagnostics = append(s.diagnostics, Diagnostic{ByteOrderMark, 0, 3})
+	}
+	if tokenType, n := s.openTag(); n > 0 {
+		s.stepLoop(n)
+		s.modeStack[len(s.modeStack)-1] = ModeScripting
+		return NewToken(s.pool, tokenType, start, n)
 	}
 	for s.step(); s.r != -1; s.step() {
-		c = s.r
-		if c == '<' && (s.peek(1) == '?') {
-			if isWhitespace(s.peek(2)) {
-				break
-			}
-			if s.peek(2) == '=' && isWhitespace(s.peek(3)) {
-				break
-			}
-			if strings.ToLower(s.peekSpanString(0, 4)) == "?php" && isWhitespace(s.peek(5)) {
+		if s.r == '<' {
+			if _, n := s.openTag(); n > 0 {
 				break
 			}
 		}
@@ -261,6 +268,9 @@ func (s *Lexer) scripting() *Token {
 	case '/':
 		return s.scriptingForwardSlash()
 	case '%':
+		if s.opts.AspTags && s.peek(1) == '>' {
+			return s.closeTag(2)
+		}
 		s.step()
 		if s.r == '=' {
 			s.step()
@@ -342,6 +352,7 @@ func (s *Lexer) scripting() *Token {
 		s.step()
 
 		s.modeStack[len(s.modeStack)-1] = ModeBacktick
+		s.open(UnterminatedBacktick, start)
 
 		return NewToken(s.pool, Backtick, start, 1)
 	case '\\':
@@ -468,6 +479,12 @@ func (s *Lexer) scriptingLessThan() *Token {
 	start := s.offset
 
 	switch s.peek(1) {
+	case '/':
+		if s.opts.ScriptTags {
+			if n := s.scriptCloseTagLength(); n > 0 {
+				return s.closeTag(n)
+			}
+		}
 	case '>':
 		s.stepLoop(2)
 		return NewToken(s.pool, ExclamationEquals, start, 2)
@@ -628,6 +645,10 @@ func (s *Lexer) scriptingQuestion() *Token {
 	s.step()
 	if s.r == '?' {
 		s.step()
+		if s.r == '=' {
+			s.step()
+			return NewToken(s.pool, QuestionQuestionEquals, start, 3)
+		}
 
 		return NewToken(s.pool, QuestionQuestion, start, 2)
 	} else if s.r == '>' {
@@ -638,6 +659,14 @@ func (s *Lexer) scriptingQuestion() *Token {
 	return NewToken(s.pool, Question, start, 1)
 }
 
+// closeTag returns the n bytes long close tag at the current position
+func (s *Lexer) closeTag(n int) *Token {
+	start := s.offset
+	s.stepLoop(n)
+	s.modeStack[len(s.modeStack)-1] = ModeInitial
+	return NewToken(s.pool, CloseTag, start, n)
+}
+
 func (s *Lexer) scriptingDollar() *Token {
 	start := s.offset
 	k := 1
@@ -655,7 +684,8 @@ func (s *Lexer) scriptingComment(start int) *Token {
 	//s.position will be on first char after # or //
 	//find first newline or closing tag
 	for c := s.r; c != -1; {
-		if c == '\n' || c == '\r' || (c == '?' && s.peek(1) == '>') {
+		if c == '\n' || c == '\r' || (c == '?' && s.peek(1) == '>') ||
+			(c == '%' && s.opts.AspTags && s.peek(1) == '>') {
 			break
 		}
 		s.step()
@@ -729,6 +759,7 @@ func (s *Lexer) scriptingSingleQuote(start int) *Token {
 			}
 			continue
 		}
+		s.unterminated(UnterminatedString, start)
 		return NewToken(s.pool, EncapsulatedAndWhitespace, start, s.offset-start)
 	}
 	return NewToken(s.pool, StringLiteral, start, s.offset-start)
@@ -771,6 +802,7 @@ func (s *Lexer) scriptingDoubleQuote(start int) *Token {
 	}
 	s.doubleQuoteScannedLength = n
 	s.modeStack[len(s.modeStack)-1] = ModeDoubleQuotes
+	s.open(UnterminatedString, start)
 	return NewToken(s.pool, DoubleQuote, start, s.offset-start)
 }
 
@@ -785,7 +817,7 @@ func (s *Lexer) scriptingLabelStart() *Token {
 	}
 	nextNonWhitespace := s.peek(i)
 
-	text := string(s.source[startPosition : s.nextOffset-1])
+	text := string(s.span(startPosition, s.nextOffset-1))
 	tokenType := Unknown
 	if firstRune == '_' {
 		switch text {
@@ -1025,15 +1057,16 @@ func (s *Lexer) scriptingHeredoc(start int) *Token {
 	s.heredocLabel = s.peekSpanString(labelStart-1, labelEnd-labelStart)
 	s.stepLoop(k)
 	t := NewToken(s.pool, StartHeredoc, start, s.offset-start)
+	s.open(UnterminatedHeredoc, start)
 	if quote == '\'' {
 		s.modeStack[len(s.modeStack)-1] = ModeNowDoc
 	} else {
 		s.modeStack[len(s.modeStack)-1] = ModeHereDoc
 	}
 	//check for end on next line
-	endHereDocLabel := string(s.source[s.nextOffset-1+len(s.heredocLabel) : s.nextOffset-1+len(s.heredocLabel)+3])
+	endHereDocLabel := string(s.span(s.nextOffset-1+len(s.heredocLabel), s.nextOffset-1+len(s.heredocLabel)+3))
 	isEndOfLine, err := regexp.MatchString("^;?(?:\r\n|\n|\r)", endHereDocLabel)
-	if err == nil && string(s.source[s.nextOffset-1:s.nextOffset-1+len(s.heredocLabel)]) == s.heredocLabel && isEndOfLine {
+	if err == nil && string(s.span(s.nextOffset-1, s.nextOffset-1+len(s.heredocLabel))) == s.heredocLabel && isEndOfLine {
 		s.modeStack[len(s.modeStack)-1] = ModeEndHereDoc
 	}
 	return t
@@ -1046,17 +1079,21 @@ func (s *Lexer) scriptingInlineCommentOrDocBlock() *Token {
 	if s.r == '*' && s.peek(1) != '/' {
 		s.step()
 		s.modeStack = append(s.modeStack, ModeDocumentBlock)
+		s.open(UnterminatedComment, start)
 		return NewToken(s.pool, DocumentCommentStart, start, s.offset-start)
 	}
 	//find comment end */
-	for s.r != -1 {
+	for {
+		if s.r == -1 {
+			s.unterminated(UnterminatedComment, start)
+			break
+		}
 		if s.r == '*' && s.peek(1) == '/' {
 			s.stepLoop(2)
 			break
 		}
 		s.step()
 	}
-	// TODO: WARN unterminated comment
 	return NewToken(s.pool, tokenType, start, s.offset-start)
 }
 
@@ -1116,6 +1153,7 @@ func (s *Lexer) doubleQuotes() *Token {
 		}
 	case '"':
 		s.modeStack[len(s.modeStack)-1] = ModeScripting
+		s.close()
 		s.step()
 		return NewToken(s.pool, DoubleQuote, start, 1)
 	}
@@ -1213,34 +1251,25 @@ func (s *Lexer) nowdoc() *Token {
 		n++
 		switch c {
 		case '\r', '\n':
+			mark := n - 1
 			if c == '\r' && s.peek(n) == '\n' {
 				n++
 			}
 			/* Check for ending label on the next line */
-			heredocLabel := s.peekSpanString(n, len(s.heredocLabel))
-			if s.peek(n) != -1 && s.heredocLabel == heredocLabel {
+			labelStart := s.nextOffset - 1 + n
+			if s.peek(n) != -1 && string(s.span(labelStart, labelStart+len(s.heredocLabel))) == s.heredocLabel {
 				k := n + len(s.heredocLabel)
 				if s.peek(k) == ';' {
 					k++
 				}
-				if s.peek(k) == '\n' || s.peek(k) == '\r' {
+				if c = s.peek(k); c == '\n' || c == '\r' || c == -1 {
 					//set position to whitespace before label
-					nl := s.peekSpanString(n-2, 2)
-					if nl == "\r\n" {
-						n -= 2
-					} else {
-						n--
-					}
+					s.stepLoop(mark)
 					s.modeStack[len(s.modeStack)-1] = ModeEndHereDoc
-					break
+					return NewToken(s.pool, EncapsulatedAndWhitespace, start, s.offset-start)
 				}
 			}
-			continue
-		default:
-			continue
 		}
-
-		break
 	}
 	s.stepLoop(n)
 	return NewToken(s.pool, EncapsulatedAndWhitespace, start, s.offset-start)
@@ -1280,8 +1309,8 @@ func (s *Lexer) heredocAny() *Token {
 				n++
 			}
 			/* Check for ending label on the next line */
-			heredocLabel := s.peekSpanString(n-1, len(s.heredocLabel))
-			if s.peek(n) != -1 && heredocLabel == s.heredocLabel {
+			labelStart := s.nextOffset - 1 + n
+			if s.peek(n) != -1 && string(s.span(labelStart, labelStart+len(s.heredocLabel))) == s.heredocLabel {
 				k := n + len(s.heredocLabel)
 				if s.peek(k) == ';' {
 					k++
@@ -1325,6 +1354,7 @@ func (s *Lexer) endHeredoc() *Token {
 	s.heredocLabel = ""
 	t := NewToken(s.pool, EndHeredoc, start, s.offset-start)
 	s.modeStack[len(s.modeStack)-1] = ModeScripting
+	s.close()
 	return t
 }
 
@@ -1346,6 +1376,7 @@ func (s *Lexer) backtick() *Token {
 		}
 	case '`':
 		s.modeStack[len(s.modeStack)-1] = ModeScripting
+		s.close()
 		s.step()
 		return NewToken(s.pool, Backtick, start, 1)
 	}
@@ -1474,7 +1505,7 @@ func (s *Lexer) lookingForVarName() *Token {
 
 // GetTokenValue returns the []rune of the token source code
 func (s *Lexer) GetTokenValue(t *Token) []rune {
-	return []rune(string(s.sourceBytes[t.Offset : t.Offset+t.Length]))
+	return []rune(string(s.span(t.Offset, t.Offset+t.Length)))
 }
 
 func isLabelStart(cp rune) bool {
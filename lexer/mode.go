@@ -14,6 +14,7 @@ const (
 	ModeVarOffset
 	ModeLookingForVarName
 	ModeDocumentBlock
+	ModeHaltCompilerData
 )
 
 var /* const */ modeStrings = []string{
@@ -28,6 +29,7 @@ var /* const */ modeStrings = []string{
 	"ModeVarOffset",
 	"ModeLookingForVarName",
 	"ModeDocumentBlock",
+	"ModeHaltCompilerData",
 }
 
 func (mode LexerMode) String() string {
@@ -0,0 +1,149 @@
+package lexer
+
+import "strings"
+
+// ShortOpenTag tells when <? is an open tag, see Options
+type ShortOpenTag uint8
+
+const (
+	// ShortOpenTagWhitespace makes <? an open tag when whitespace follows it,
+	// so that <?xml stays text
+	ShortOpenTagWhitespace ShortOpenTag = iota
+	// ShortOpenTagOn makes <? an open tag as short_open_tag=On does
+	ShortOpenTagOn
+	// ShortOpenTagOff leaves <? as text as short_open_tag=Off does
+	ShortOpenTagOff
+)
+
+// Options mirror the ini settings of PHP which change how a source is lexed.
+// <?php and <?= open PHP whatever they are.
+type Options struct {
+	ShortOpenTag ShortOpenTag
+	// AspTags makes <% and <%= open tags and %> a close tag, as asp_tags=On
+	// did before PHP 7
+	AspTags bool
+	// ScriptTags makes <script language="php"> an open tag and </script> a
+	// close tag, as they were before PHP 7
+	ScriptTags bool
+}
+
+// SetOptions sets the options of the lexer, which apply from the next token
+func (s *Lexer) SetOptions(opts Options) {
+	s.opts = opts
+}
+
+// openTag returns the type and length of the open tag at the current
+// position, including the whitespace character after it, or a zero length
+// when there is none
+func (s *Lexer) openTag() (TokenType, int) {
+	if s.r != '<' {
+		return Undefined, 0
+	}
+	switch s.peek(1) {
+	case '?':
+		if s.peek(2) == '=' {
+			return OpenTagEcho, s.openTagWhitespace(3)
+		}
+		if s.hasFoldAt(1, "?php") && (isWhitespace(s.peek(5)) || s.peek(5) == -1) {
+			return OpenTag, s.openTagWhitespace(5)
+		}
+		switch s.opts.ShortOpenTag {
+		case ShortOpenTagWhitespace:
+			if isWhitespace(s.peek(2)) {
+				return OpenTag, s.openTagWhitespace(2)
+			}
+		case ShortOpenTagOn:
+			return OpenTag, s.openTagWhitespace(2)
+		}
+	case '%':
+		if !s.opts.AspTags {
+			break
+		}
+		if s.peek(2) == '=' {
+			return OpenTagEcho, s.openTagWhitespace(3)
+		}
+		return OpenTag, s.openTagWhitespace(2)
+	case 's', 'S':
+		if s.opts.ScriptTags {
+			return OpenTag, s.scriptTagLength()
+		}
+	}
+	return Undefined, 0
+}
+
+// openTagWhitespace returns n and the length of the newline or whitespace
+// character n bytes on
+func (s *Lexer) openTagWhitespace(n int) int {
+	if s.peek(n) == '\r' && s.peek(n+1) == '\n' {
+		return n + 2
+	}
+	if isWhitespace(s.peek(n)) {
+		return n + 1
+	}
+	return n
+}
+
+// scriptTagLength returns the length of <script language="php"> at the
+// current position, with the language quoted or not, or 0
+func (s *Lexer) scriptTagLength() int {
+	n := len("<script")
+	if !s.hasFoldAt(0, "<script") || !isWhitespace(s.peek(n)) {
+		return 0
+	}
+	n = s.skipWhitespace(n)
+	if !s.hasFoldAt(n, "language") {
+		return 0
+	}
+	n = s.skipWhitespace(n + len("language"))
+	if s.peek(n) != '=' {
+		return 0
+	}
+	n = s.skipWhitespace(n + 1)
+	quote := s.peek(n)
+	if quote == '"' || quote == '\'' {
+		n++
+	}
+	if !s.hasFoldAt(n, "php") {
+		return 0
+	}
+	n += len("php")
+	if quote == '"' || quote == '\'' {
+		if s.peek(n) != quote {
+			return 0
+		}
+		n++
+	}
+	n = s.skipWhitespace(n)
+	if s.peek(n) != '>' {
+		return 0
+	}
+	return n + 1
+}
+
+// scriptCloseTagLength returns the length of </script> at the current
+// position, or 0
+func (s *Lexer) scriptCloseTagLength() int {
+	n := len("</script")
+	if !s.hasFoldAt(0, "</script") {
+		return 0
+	}
+	n = s.skipWhitespace(n)
+	if s.peek(n) != '>' {
+		return 0
+	}
+	return n + 1
+}
+
+func (s *Lexer) skipWhitespace(n int) int {
+	for isWhitespace(s.peek(n)) {
+		n++
+	}
+	return n
+}
+
+// hasFoldAt reports whether the text n bytes from the current position is
+// text under case folding
+func (s *Lexer) hasFoldAt(n int, text string) bool {
+	start := s.offset + n
+	return strings.EqualFold(string(s.span(start, start+len(text))), text)
+}
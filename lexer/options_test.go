@@ -0,0 +1,69 @@
+package lexer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		source   string
+		opts     Options
+		expected []string
+	}{
+		{"a<?php $b", Options{}, []string{"Text a", "OpenTag <?php ", "VariableName $b"}},
+		{"<?php", Options{}, []string{"OpenTag <?php"}},
+		{"<?=$a?>", Options{}, []string{"OpenTagEcho <?=", "VariableName $a", "CloseTag ?>"}},
+		{"<?= $a?>", Options{ShortOpenTag: ShortOpenTagOff}, []string{"OpenTagEcho <?= ", "VariableName $a", "CloseTag ?>"}},
+		{"<? $a?>", Options{}, []string{"OpenTag <? ", "VariableName $a", "CloseTag ?>"}},
+		{"<?xml ?>", Options{}, []string{"Text <?xml ?>"}},
+		{"<?echo 1?>", Options{}, []string{"Text <?echo 1?>"}},
+		{"<?echo 1?>", Options{ShortOpenTag: ShortOpenTagOn}, []string{"OpenTag <?", "Echo echo", "Whitespace  ",
+			"IntegerLiteral 1", "CloseTag ?>"}},
+		{"a<? $b ?>", Options{ShortOpenTag: ShortOpenTagOff}, []string{"Text a<? $b ?>"}},
+		{"a<?php $b ?>", Options{ShortOpenTag: ShortOpenTagOff}, []string{"Text a", "OpenTag <?php ", "VariableName $b",
+			"Whitespace  ", "CloseTag ?>"}},
+
+		{"<% $a %>b", Options{}, []string{"Text <% $a %>b"}},
+		{"<% $a %>b", Options{AspTags: true}, []string{"OpenTag <% ", "VariableName $a", "Whitespace  ", "CloseTag %>",
+			"Text b"}},
+		{"x<%=$a%>", Options{AspTags: true}, []string{"Text x", "OpenTagEcho <%=", "VariableName $a", "CloseTag %>"}},
+		{"<?php $a % 2; // c %>d", Options{AspTags: true}, []string{"OpenTag <?php ", "VariableName $a", "Whitespace  ",
+			"Percent %", "Whitespace  ", "IntegerLiteral 2", "Semicolon ;", "Whitespace  ", "Comment // c ",
+			"CloseTag %>", "Text d"}},
+		{"<?php $a %> 2;", Options{}, []string{"OpenTag <?php ", "VariableName $a", "Whitespace  ", "Percent %",
+			"GreaterThan >", "Whitespace  ", "IntegerLiteral 2", "Semicolon ;"}},
+
+		{`<script language="php">$a;</script>b`, Options{}, []string{`Text <script language="php">$a;</script>b`}},
+		{`a<script language="php">$a;</script>b`, Options{ScriptTags: true}, []string{"Text a",
+			`OpenTag <script language="php">`, "VariableName $a", "Semicolon ;", "CloseTag </script>", "Text b"}},
+		{"<SCRIPT Language = 'PHP' >$a</script >", Options{ScriptTags: true}, []string{
+			"OpenTag <SCRIPT Language = 'PHP' >", "VariableName $a", "CloseTag </script >"}},
+		{"<script language=php>$a < $b", Options{ScriptTags: true}, []string{"OpenTag <script language=php>",
+			"VariableName $a", "Whitespace  ", "LessThan <", "Whitespace  ", "VariableName $b"}},
+		{`<script language="js">a</script>`, Options{ScriptTags: true}, []string{`Text <script language="js">a</script>`}},
+	}
+
+	for _, test := range tests {
+		actual := []string{}
+		for it := Tokens([]byte(test.source)).WithOptions(test.opts); it.Next(); {
+			actual = append(actual, it.Token().Type.String()+" "+string(it.Text()))
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%q with %+v: expected %q, got %q", test.source, test.opts, test.expected, actual)
+		}
+	}
+}
+
+func TestStateOptions(t *testing.T) {
+	source := []byte("<?php $a")
+	l := NewLexer(source, nil, 0)
+	state := l.State()
+	l.SetOptions(Options{AspTags: true})
+	if state.Equal(l.State()) {
+		t.Errorf("expected states with other options to differ")
+	}
+	if restored := NewLexerFromState(source, l.State()); !restored.State().Equal(l.State()) {
+		t.Errorf("expected a restored state to keep the options")
+	}
+}
@@ -2,6 +2,9 @@ package lexer
 
 const DefaultBlockSize = 1024
 
+// Pool allocates tokens from blocks. Tokens are never handed out twice, so a
+// pool can serve lexers of several sources one after another while the
+// tokens of earlier sources are in use, but it is not safe for concurrent use.
 type Pool struct {
 	block []Token
 	off   int
@@ -0,0 +1,143 @@
+package lexer
+
+import (
+	"io"
+)
+
+// DefaultBufferSize is the buffer size of NewReaderLexer when it is not
+// positive
+const DefaultBufferSize = 64 * 1024
+
+const maxOffset = int(^uint(0) >> 1)
+
+// maxEmptyReads is the number of reads returning no bytes and no error after
+// which reading fails with io.ErrNoProgress, as in bufio
+const maxEmptyReads = 100
+
+// sourceReader reads the source of a lexer in chunks. A token which reaches
+// the end of the bytes read is lexed again from the state saved at its start
+// once more bytes are read, so that lexing itself does not check for bytes
+// to read. Bytes before the token and before the retained offset are dropped
+// from the buffer to make room for new ones.
+type sourceReader struct {
+	r          io.Reader
+	err        error
+	size       int
+	tokenStart int
+	retain     int
+
+	// the state of the lexer at the start of the token
+	nextOffset               int
+	char                     rune
+	modeStack                []LexerMode
+	doubleQuoteScannedLength int
+	heredocLabel             string
+	opened                   []Diagnostic
+	diagnostics              int
+	halt                     int
+	member                   bool
+}
+
+// NewReaderLexer returns a lexer which reads its source from r in chunks of
+// bufferSize bytes. The buffer only keeps the bytes of the token being lexed,
+// and the bytes from the offset given to Retain on, so it grows beyond
+// bufferSize only for longer tokens such as big heredocs. Offsets of tokens
+// are from the start of r.
+func NewReaderLexer(r io.Reader, modeStack []LexerMode, bufferSize int) *Lexer {
+	return NewReaderLexerWithPool(r, modeStack, bufferSize, NewPool(DefaultBlockSize))
+}
+
+// NewReaderLexerWithPool is NewReaderLexer allocating its tokens from pool
+func NewReaderLexerWithPool(r io.Reader, modeStack []LexerMode, bufferSize int, pool *Pool) *Lexer {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	reader := &sourceReader{r: r, size: bufferSize, retain: maxOffset}
+	return newLexer(nil, modeStack, 0, pool, reader)
+}
+
+// Retain keeps the bytes from offset on, which GetTokenValue needs for tokens
+// lexed before the last one, until the next call. It only matters to lexers
+// reading from an io.Reader.
+func (s *Lexer) Retain(offset int) {
+	if s.reader != nil {
+		s.reader.retain = offset
+	}
+}
+
+// Err returns the error reading the source, other than io.EOF
+func (s *Lexer) Err() error {
+	if s.reader == nil || s.reader.err == io.EOF {
+		return nil
+	}
+	return s.reader.err
+}
+
+func (r *sourceReader) lex(s *Lexer) *Token {
+	r.tokenStart = s.offset
+	r.nextOffset = s.nextOffset
+	r.char = s.r
+	r.modeStack = append(r.modeStack[:0], s.modeStack...)
+	r.doubleQuoteScannedLength = s.doubleQuoteScannedLength
+	r.heredocLabel = s.heredocLabel
+	r.opened = append(r.opened[:0], s.opened...)
+	r.diagnostics = len(s.diagnostics)
+	r.halt = s.halt
+	r.member = s.member
+	for {
+		s.bufferEnd = false
+		t := s.lex()
+		if !s.bufferEnd || r.err != nil {
+			return t
+		}
+		s.offset = r.tokenStart
+		s.nextOffset = r.nextOffset
+		s.r = r.char
+		s.modeStack = append(s.modeStack[:0], r.modeStack...)
+		s.doubleQuoteScannedLength = r.doubleQuoteScannedLength
+		s.heredocLabel = r.heredocLabel
+		s.opened = append(s.opened[:0], r.opened...)
+		s.diagnostics = s.diagnostics[:r.diagnostics]
+		s.halt = r.halt
+		s.member = r.member
+		r.read(s)
+		if s.r == -1 && s.offset < s.sourceEnd() {
+			// the token started at the end of the bytes read
+			s.r = rune(s.sourceBytes[s.offset-s.base])
+		}
+	}
+}
+
+// read reads at least as many bytes as are kept, so that a long token is
+// lexed again a logarithmic number of times
+func (r *sourceReader) read(s *Lexer) {
+	keep := r.tokenStart
+	if r.retain < keep {
+		keep = r.retain
+	}
+	buf := s.sourceBytes
+	if drop := keep - s.base; drop > 0 {
+		buf = buf[:copy(buf, buf[drop:])]
+		s.base += drop
+	}
+	want := 2 * len(buf)
+	if want < r.size {
+		want = r.size
+	}
+	if cap(buf) < want {
+		grown := make([]byte, len(buf), want)
+		copy(grown, buf)
+		buf = grown
+	}
+	for empty := 0; len(buf) < want && r.err == nil; {
+		n, err := r.r.Read(buf[len(buf):want])
+		buf = buf[:len(buf)+n]
+		r.err = err
+		if n > 0 {
+			empty = 0
+		} else if empty++; empty == maxEmptyReads && err == nil {
+			r.err = io.ErrNoProgress
+		}
+	}
+	s.sourceBytes = buf
+}
@@ -0,0 +1,82 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReaderLexer(t *testing.T) {
+	var source bytes.Buffer
+	source.WriteString("<html><?php\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&source, `/**
+ * Entry %d
+ * @var array<string, int>
+ */
+$table[%d] = <<<EOT
+ {$name} %d "quoted" }
+EOT;
+$s = "a {$b[%d]} c" . 'd' . <<<NOW
+here
+NOW;
+// done ?>text<?php
+`, i, i, i, i)
+	}
+	// a token longer than the buffer
+	source.WriteString("$long = '" + strings.Repeat("x", 100000) + "';\n")
+
+	expected := lexAll(NewLexer(source.Bytes(), nil, 0))
+	l := NewReaderLexer(iotest.OneByteReader(bytes.NewReader(source.Bytes())), nil, 4096)
+	actual := lexAll(l)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("token %d: expected %v, got %v", i, expected[i], actual[i])
+		}
+	}
+	if cap(l.sourceBytes) > 256*1024 {
+		t.Errorf("expected a bounded buffer, got %d bytes for a source of %d", cap(l.sourceBytes), source.Len())
+	}
+	if l.base == 0 {
+		t.Errorf("expected the buffer to have dropped bytes")
+	}
+}
+
+// stuckReader returns no bytes and no error once its source is read
+type stuckReader struct {
+	source []byte
+}
+
+func (r *stuckReader) Read(p []byte) (int, error) {
+	n := copy(p, r.source)
+	r.source = r.source[n:]
+	return n, nil
+}
+
+func TestReaderNoProgress(t *testing.T) {
+	l := NewReaderLexer(&stuckReader{[]byte("<?php echo 1;")}, nil, 4)
+	tokens := lexAll(l)
+	if l.Err() != io.ErrNoProgress {
+		t.Errorf("expected io.ErrNoProgress, got %v", l.Err())
+	}
+	if len(tokens) < 2 || tokens[0].Type != OpenTag {
+		t.Errorf("expected the tokens read before, got %v", tokens)
+	}
+}
+
+func lexAll(l *Lexer) []Token {
+	tokens := []Token{}
+	for {
+		t := l.Lex()
+		tokens = append(tokens, *t)
+		if t.Type == EndOfFile {
+			return tokens
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package lexer
+
+// State is a checkpoint of a lexer between two tokens, from which lexing can
+// resume exactly as if it had never stopped, see Lexer.State
+type State struct {
+	offset       int
+	modeStack    []LexerMode
+	heredocLabel string
+	opened       []Diagnostic
+	opts         Options
+	halt         int
+	member       bool
+}
+
+// State returns the state of the lexer before the next token. Text the lexer
+// has looked at beyond that token is not part of it, so the state also
+// resumes lexing a source which was edited after its offset.
+func (s *Lexer) State() State {
+	return State{
+		offset:       s.offset,
+		modeStack:    s.ModeStack(),
+		heredocLabel: s.heredocLabel,
+		opened:       append(s.opened[:0:0], s.opened...),
+		opts:         s.opts,
+		halt:         s.halt,
+		member:       s.member,
+	}
+}
+
+// Restore resumes lexing from state, which was taken from this lexer or from
+// a lexer of the same source up to the offset of state. Lexers reading from
+// an io.Reader cannot be restored.
+func (s *Lexer) Restore(state State) {
+	s.offset = state.offset
+	s.nextOffset = state.offset
+	s.modeStack = append(s.modeStack[:0], state.modeStack...)
+	s.heredocLabel = state.heredocLabel
+	s.doubleQuoteScannedLength = -1
+	s.opened = append(s.opened[:0], state.opened...)
+	s.opts = state.opts
+	s.halt = state.halt
+	s.member = state.member
+	s.r = -1
+	s.step()
+}
+
+// NewLexerFromState returns a lexer resuming from state in source, with the
+// options of the lexer state was taken from
+func NewLexerFromState(source []byte, state State) *Lexer {
+	lexer := NewLexer(source, nil, state.offset)
+	lexer.Restore(state)
+	return lexer
+}
+
+// Offset returns the byte offset of the next token
+func (state State) Offset() int {
+	return state.offset
+}
+
+// ModeStack returns a copy of the mode stack of state
+func (state State) ModeStack() []LexerMode {
+	return append(state.modeStack[:0:0], state.modeStack...)
+}
+
+// Equal reports whether lexing from both states gives the same tokens given
+// the same text, such as when the state at the end of an edited line is what
+// it was before the edit and the lines after need not be lexed again
+func (state State) Equal(other State) bool {
+	if len(state.modeStack) != len(other.modeStack) ||
+		state.heredocLabel != other.heredocLabel || len(state.opened) != len(other.opened) ||
+		state.opts != other.opts || state.halt != other.halt || state.member != other.member {
+		return false
+	}
+	for i, mode := range state.modeStack {
+		if mode != other.modeStack[i] {
+			return false
+		}
+	}
+	// unclosed constructs are reported at the offset they were opened at
+	for i, d := range state.opened {
+		if d != other.opened[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -140,6 +140,7 @@ const (
 	CloseBracket
 	CloseParenthesis
 	QuestionQuestion
+	QuestionQuestionEquals
 	Bar
 	BarBar
 	Caret
@@ -200,6 +201,19 @@ const (
 	AtSince
 	AtThrows
 	AtVar
+	AtTemplate
+	AtTemplateCovariant
+	AtTemplateContravariant
+	AtExtends
+	AtImplements
+	AtUse
+	AtMixin
+	AtParamOut
+	AtAssert
+	AtAssertIfTrue
+	AtAssertIfFalse
+	AtSee
+	AtInheritDoc
 	DocumentCommentTagNameAnchorEnd
 
 	DocumentCommentEnd
@@ -207,6 +221,9 @@ const (
 	//Comments whitespace
 	Comment
 	Whitespace
+
+	// HaltCompilerData is the data after __halt_compiler();
+	HaltCompilerData
 )
 
 //go:generate stringer -type=TokenType
@@ -229,8 +246,37 @@ func NewToken(pool *Pool, tokenType TokenType, offset int, length int) *Token {
 func (token Token) AstNode() {
 }
 
+// Text returns the text of the token in source, sharing its bytes
+func (token Token) Text(source []byte) []byte {
+	return source[token.Offset : token.Offset+token.Length]
+}
+
+// Is reports whether the token is of one of types
+func (token Token) Is(types ...TokenType) bool {
+	for _, t := range types {
+		if token.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
 func (token Token) String() string {
 	str := token.Type.String() + " " + strconv.Itoa(token.Offset) + " " + strconv.Itoa(token.Length)
 
 	return str
 }
+
+var tokenTypesByName = map[string]TokenType{}
+
+func init() {
+	for t := TokenType(0); int(t) < len(_TokenType_index)-1; t++ {
+		tokenTypesByName[t.String()] = t
+	}
+}
+
+// TokenTypeByName returns the token type whose String() is name
+func TokenTypeByName(name string) (TokenType, bool) {
+	t, ok := tokenTypesByName[name]
+	return t, ok
+}
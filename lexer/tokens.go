@@ -0,0 +1,81 @@
+package lexer
+
+// TokenIterator iterates over the tokens of a source, see Tokens
+type TokenIterator struct {
+	lexer      *Lexer
+	source     []byte
+	skipHidden bool
+	token      Token
+	modeStack  []LexerMode
+}
+
+// Tokens returns an iterator over the tokens of source, which stops before
+// EndOfFile:
+//
+//	for it := lexer.Tokens(source); it.Next(); {
+//		fmt.Println(it.Token().Type, string(it.Text()))
+//	}
+//
+// Tokens are returned by value, so iterating does not allocate for each of
+// them.
+func Tokens(source []byte) *TokenIterator {
+	return &TokenIterator{
+		// the token of the pool is taken again for every token
+		lexer:  NewLexerWithPool(source, nil, 0, NewPool(1)),
+		source: source,
+	}
+}
+
+// SkipHidden makes the iterator skip Whitespace and Comment tokens
+func (it *TokenIterator) SkipHidden() *TokenIterator {
+	it.skipHidden = true
+	return it
+}
+
+// WithOptions sets the options of the lexer, see Options
+func (it *TokenIterator) WithOptions(opts Options) *TokenIterator {
+	it.lexer.SetOptions(opts)
+	return it
+}
+
+// Next advances to the next token and reports whether there is one
+func (it *TokenIterator) Next() bool {
+	for {
+		it.modeStack = append(it.modeStack[:0], it.lexer.modeStack...)
+		it.lexer.pool.off = 0
+		it.token = *it.lexer.Lex()
+		if it.token.Type == EndOfFile {
+			return false
+		}
+		if !it.skipHidden || (it.token.Type != Comment && it.token.Type != Whitespace) {
+			return true
+		}
+	}
+}
+
+// Token returns the current token
+func (it *TokenIterator) Token() Token {
+	return it.token
+}
+
+// Text returns the text of the current token, which shares the bytes of the
+// source
+func (it *TokenIterator) Text() []byte {
+	return it.token.Text(it.source)
+}
+
+// Is reports whether the current token is of one of types
+func (it *TokenIterator) Is(types ...TokenType) bool {
+	return it.token.Is(types...)
+}
+
+// ModeStack returns the mode stack the current token was lexed in, innermost
+// mode last. It is only valid until the next call to Next.
+func (it *TokenIterator) ModeStack() []LexerMode {
+	return it.modeStack
+}
+
+// Diagnostics returns the lexical errors found so far, see Lexer.Diagnostics
+func (it *TokenIterator) Diagnostics() []Diagnostic {
+	return it.lexer.Diagnostics()
+}
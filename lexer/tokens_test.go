@@ -0,0 +1,71 @@
+package lexer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tokensSource = "<?php\n// c\n$a = \"b {$c}\"; /* d */ echo `e`;\n"
+
+func TestTokens(t *testing.T) {
+	source := []byte(tokensSource)
+	expected := lexAll(NewLexer(source, nil, 0))
+	expected = expected[:len(expected)-1]
+
+	actual := []Token{}
+	for it := Tokens(source); it.Next(); {
+		actual = append(actual, it.Token())
+		if text := it.Text(); string(text) != tokensSource[it.Token().Offset:it.Token().Offset+it.Token().Length] {
+			t.Errorf("unexpected text %q of %v", text, it.Token())
+		} else if len(text) > 0 && &text[0] != &source[it.Token().Offset] {
+			t.Errorf("expected the text of %v to share the source", it.Token())
+		}
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	texts := []string{}
+	for it := Tokens(source).SkipHidden(); it.Next(); {
+		if it.Is(Whitespace, Comment) {
+			t.Errorf("unexpected hidden token %v", it.Token())
+		}
+		texts = append(texts, string(it.Text()))
+	}
+	if joined := strings.Join(texts, " "); joined != "<?php\n $a = \" b  { $c } \" ; echo ` e ` ;" {
+		t.Errorf("unexpected tokens %q", joined)
+	}
+}
+
+func TestTokensModeStack(t *testing.T) {
+	modes := map[string][]LexerMode{}
+	for it := Tokens([]byte(tokensSource)).SkipHidden(); it.Next(); {
+		modes[string(it.Text())] = append([]LexerMode(nil), it.ModeStack()...)
+	}
+	for text, expected := range map[string][]LexerMode{
+		"<?php\n": {ModeInitial},
+		"$a":      {ModeScripting},
+		"b ":      {ModeDoubleQuotes},
+		"$c":      {ModeDoubleQuotes, ModeScripting},
+		"e":       {ModeBacktick},
+	} {
+		if !reflect.DeepEqual(modes[text], expected) {
+			t.Errorf("%q: expected %v, got %v", text, expected, modes[text])
+		}
+	}
+}
+
+func TestTokensAllocations(t *testing.T) {
+	source := []byte(strings.Repeat(tokensSource, 100))
+	it := Tokens(source)
+	it.Next()
+	allocs := testing.AllocsPerRun(100, func() {
+		if !it.Next() {
+			it = Tokens(source)
+		}
+	})
+	if allocs > 0.1 {
+		t.Errorf("expected no allocations per token, got %v", allocs)
+	}
+}
@@ -133,73 +133,88 @@ func _() {
 	_ = x[CloseBracket-122]
 	_ = x[CloseParenthesis-123]
 	_ = x[QuestionQuestion-124]
-	_ = x[Bar-125]
-	_ = x[BarBar-126]
-	_ = x[Caret-127]
-	_ = x[Dot-128]
-	_ = x[DotEquals-129]
-	_ = x[CurlyOpen-130]
-	_ = x[MinusMinus-131]
-	_ = x[ForwardslashEquals-132]
-	_ = x[DollarCurlyOpen-133]
-	_ = x[FatArrow-134]
-	_ = x[ColonColon-135]
-	_ = x[Ellipsis-136]
-	_ = x[PlusPlus-137]
-	_ = x[EqualsEquals-138]
-	_ = x[GreaterThanEquals-139]
-	_ = x[EqualsEqualsEquals-140]
-	_ = x[ExclamationEquals-141]
-	_ = x[ExclamationEqualsEquals-142]
-	_ = x[LessThanEquals-143]
-	_ = x[Spaceship-144]
-	_ = x[Minus-145]
-	_ = x[MinusEquals-146]
-	_ = x[PercentEquals-147]
-	_ = x[AsteriskEquals-148]
-	_ = x[Backslash-149]
-	_ = x[BooleanCast-150]
-	_ = x[UnsetCast-151]
-	_ = x[StringCast-152]
-	_ = x[ObjectCast-153]
-	_ = x[IntegerCast-154]
-	_ = x[FloatCast-155]
-	_ = x[StartHeredoc-156]
-	_ = x[ArrayCast-157]
-	_ = x[OpenTag-158]
-	_ = x[OpenTagEcho-159]
-	_ = x[CloseTag-160]
-	_ = x[DocumentCommentStart-161]
-	_ = x[DocumentCommentVersion-162]
-	_ = x[DocumentCommentText-163]
-	_ = x[DocumentCommentUnknown-164]
-	_ = x[DocumentCommentStartline-165]
-	_ = x[DocumentCommentEndline-166]
-	_ = x[DocumentCommentTagName-167]
-	_ = x[DocumentCommentTagNameAnchorStart-168]
-	_ = x[AtAuthor-169]
-	_ = x[AtDeprecated-170]
-	_ = x[AtGlobal-171]
-	_ = x[AtLicense-172]
-	_ = x[AtLink-173]
-	_ = x[AtMethod-174]
-	_ = x[AtParam-175]
-	_ = x[AtProperty-176]
-	_ = x[AtPropertyRead-177]
-	_ = x[AtPropertyWrite-178]
-	_ = x[AtReturn-179]
-	_ = x[AtSince-180]
-	_ = x[AtThrows-181]
-	_ = x[AtVar-182]
-	_ = x[DocumentCommentTagNameAnchorEnd-183]
-	_ = x[DocumentCommentEnd-184]
-	_ = x[Comment-185]
-	_ = x[Whitespace-186]
+	_ = x[QuestionQuestionEquals-125]
+	_ = x[Bar-126]
+	_ = x[BarBar-127]
+	_ = x[Caret-128]
+	_ = x[Dot-129]
+	_ = x[DotEquals-130]
+	_ = x[CurlyOpen-131]
+	_ = x[MinusMinus-132]
+	_ = x[ForwardslashEquals-133]
+	_ = x[DollarCurlyOpen-134]
+	_ = x[FatArrow-135]
+	_ = x[ColonColon-136]
+	_ = x[Ellipsis-137]
+	_ = x[PlusPlus-138]
+	_ = x[EqualsEquals-139]
+	_ = x[GreaterThanEquals-140]
+	_ = x[EqualsEqualsEquals-141]
+	_ = x[ExclamationEquals-142]
+	_ = x[ExclamationEqualsEquals-143]
+	_ = x[LessThanEquals-144]
+	_ = x[Spaceship-145]
+	_ = x[Minus-146]
+	_ = x[MinusEquals-147]
+	_ = x[PercentEquals-148]
+	_ = x[AsteriskEquals-149]
+	_ = x[Backslash-150]
+	_ = x[BooleanCast-151]
+	_ = x[UnsetCast-152]
+	_ = x[StringCast-153]
+	_ = x[ObjectCast-154]
+	_ = x[IntegerCast-155]
+	_ = x[FloatCast-156]
+	_ = x[StartHeredoc-157]
+	_ = x[ArrayCast-158]
+	_ = x[OpenTag-159]
+	_ = x[OpenTagEcho-160]
+	_ = x[CloseTag-161]
+	_ = x[DocumentCommentStart-162]
+	_ = x[DocumentCommentVersion-163]
+	_ = x[DocumentCommentText-164]
+	_ = x[DocumentCommentUnknown-165]
+	_ = x[DocumentCommentStartline-166]
+	_ = x[DocumentCommentEndline-167]
+	_ = x[DocumentCommentTagName-168]
+	_ = x[DocumentCommentTagNameAnchorStart-169]
+	_ = x[AtAuthor-170]
+	_ = x[AtDeprecated-171]
+	_ = x[AtGlobal-172]
+	_ = x[AtLicense-173]
+	_ = x[AtLink-174]
+	_ = x[AtMethod-175]
+	_ = x[AtParam-176]
+	_ = x[AtProperty-177]
+	_ = x[AtPropertyRead-178]
+	_ = x[AtPropertyWrite-179]
+	_ = x[AtReturn-180]
+	_ = x[AtSince-181]
+	_ = x[AtThrows-182]
+	_ = x[AtVar-183]
+	_ = x[AtTemplate-184]
+	_ = x[AtTemplateCovariant-185]
+	_ = x[AtTemplateContravariant-186]
+	_ = x[AtExtends-187]
+	_ = x[AtImplements-188]
+	_ = x[AtUse-189]
+	_ = x[AtMixin-190]
+	_ = x[AtParamOut-191]
+	_ = x[AtAssert-192]
+	_ = x[AtAssertIfTrue-193]
+	_ = x[AtAssertIfFalse-194]
+	_ = x[AtSee-195]
+	_ = x[AtInheritDoc-196]
+	_ = x[DocumentCommentTagNameAnchorEnd-197]
+	_ = x[DocumentCommentEnd-198]
+	_ = x[Comment-199]
+	_ = x[Whitespace-200]
+	_ = x[HaltCompilerData-201]
 }
 
-const _TokenType_name = "UndefinedUnknownEndOfFileAbstractArrayAsBreakCallableCaseCatchClassClassConstantCloneConstContinueDeclareDefaultDoEchoElseElseIfEmptyEndDeclareEndForEndForeachEndIfEndSwitchEndWhileEndHeredocEvalExitExtendsFinalFinallyForForEachFunctionFnGlobalGotoHaltCompilerIfImplementsIncludeIncludeOnceInstanceOfInsteadOfInterfaceIssetListAndOrXorNamespaceNewPrintPrivatePublicProtectedRequireRequireOnceReturnStaticSwitchThrowTraitTryUnsetUseVarWhileYieldYieldFromDirectoryConstantFileConstantLineConstantFunctionConstantMethodConstantNamespaceConstantTraitConstantStringLiteralFloatingLiteralEncapsulatedAndWhitespaceTextIntegerLiteralNameVariableNameEqualsTildeColonSemicolonExclamationDollarForwardSlashPercentCommaAtSymbolBacktickQuestionDoubleQuoteSingleQuoteLessThanGreaterThanAsteriskAmpersandAmpersandAmpersandAmpersandEqualsCaretEqualsLessThanLessThanLessThanLessThanEqualsGreaterThanGreaterThanGreaterThanGreaterThanEqualsBarEqualsPlusPlusEqualsAsteriskAsteriskAsteriskAsteriskEqualsArrowOpenBraceOpenBracketOpenParenthesisCloseBraceCloseBracketCloseParenthesisQuestionQuestionBarBarBarCaretDotDotEqualsCurlyOpenMinusMinusForwardslashEqualsDollarCurlyOpenFatArrowColonColonEllipsisPlusPlusEqualsEqualsGreaterThanEqualsEqualsEqualsEqualsExclamationEqualsExclamationEqualsEqualsLessThanEqualsSpaceshipMinusMinusEqualsPercentEqualsAsteriskEqualsBackslashBooleanCastUnsetCastStringCastObjectCastIntegerCastFloatCastStartHeredocArrayCastOpenTagOpenTagEchoCloseTagDocumentCommentStartDocumentCommentVersionDocumentCommentTextDocumentCommentUnknownDocumentCommentStartlineDocumentCommentEndlineDocumentCommentTagNameDocumentCommentTagNameAnchorStartAtAuthorAtDeprecatedAtGlobalAtLicenseAtLinkAtMethodAtParamAtPropertyAtPropertyReadAtPropertyWriteAtReturnAtSinceAtThrowsAtVarDocumentCommentTagNameAnchorEndDocumentCommentEndCommentWhitespace"
+const _TokenType_name = "UndefinedUnknownEndOfFileAbstractArrayAsBreakCallableCaseCatchClassClassConstantCloneConstContinueDeclareDefaultDoEchoElseElseIfEmptyEndDeclareEndForEndForeachEndIfEndSwitchEndWhileEndHeredocEvalExitExtendsFinalFinallyForForEachFunctionFnGlobalGotoHaltCompilerIfImplementsIncludeIncludeOnceInstanceOfInsteadOfInterfaceIssetListAndOrXorNamespaceNewPrintPrivatePublicProtectedRequireRequireOnceReturnStaticSwitchThrowTraitTryUnsetUseVarWhileYieldYieldFromDirectoryConstantFileConstantLineConstantFunctionConstantMethodConstantNamespaceConstantTraitConstantStringLiteralFloatingLiteralEncapsulatedAndWhitespaceTextIntegerLiteralNameVariableNameEqualsTildeColonSemicolonExclamationDollarForwardSlashPercentCommaAtSymbolBacktickQuestionDoubleQuoteSingleQuoteLessThanGreaterThanAsteriskAmpersandAmpersandAmpersandAmpersandEqualsCaretEqualsLessThanLessThanLessThanLessThanEqualsGreaterThanGreaterThanGreaterThanGreaterThanEqualsBarEqualsPlusPlusEqualsAsteriskAsteriskAsteriskAsteriskEqualsArrowOpenBraceOpenBracketOpenParenthesisCloseBraceCloseBracketCloseParenthesisQuestionQuestionQuestionQuestionEqualsBarBarBarCaretDotDotEqualsCurlyOpenMinusMinusForwardslashEqualsDollarCurlyOpenFatArrowColonColonEllipsisPlusPlusEqualsEqualsGreaterThanEqualsEqualsEqualsEqualsExclamationEqualsExclamationEqualsEqualsLessThanEqualsSpaceshipMinusMinusEqualsPercentEqualsAsteriskEqualsBackslashBooleanCastUnsetCastStringCastObjectCastIntegerCastFloatCastStartHeredocArrayCastOpenTagOpenTagEchoCloseTagDocumentCommentStartDocumentCommentVersionDocumentCommentTextDocumentCommentUnknownDocumentCommentStartlineDocumentCommentEndlineDocumentCommentTagNameDocumentCommentTagNameAnchorStartAtAuthorAtDeprecatedAtGlobalAtLicenseAtLinkAtMethodAtParamAtPropertyAtPropertyReadAtPropertyWriteAtReturnAtSinceAtThrowsAtVarAtTemplateAtTemplateCovariantAtTemplateContravariantAtExtendsAtImplementsAtUseAtMixinAtParamOutAtAssertAtAssertIfTrueAtAssertIfFalseAtSeeAtInheritDocDocumentCommentTagNameAnchorEndDocumentCommentEndCommentWhitespaceHaltCompilerData"
 
-var _TokenType_index = [...]uint16{0, 9, 16, 25, 33, 38, 40, 45, 53, 57, 62, 67, 80, 85, 90, 98, 105, 112, 114, 118, 122, 128, 133, 143, 149, 159, 164, 173, 181, 191, 195, 199, 206, 211, 218, 221, 228, 236, 238, 244, 248, 260, 262, 272, 279, 290, 300, 309, 318, 323, 327, 330, 332, 335, 344, 347, 352, 359, 365, 374, 381, 392, 398, 404, 410, 415, 420, 423, 428, 431, 434, 439, 444, 453, 470, 482, 494, 510, 524, 541, 554, 567, 582, 607, 611, 625, 629, 641, 647, 652, 657, 666, 677, 683, 695, 702, 707, 715, 723, 731, 742, 753, 761, 772, 780, 798, 807, 822, 833, 849, 871, 893, 921, 930, 934, 944, 960, 982, 987, 996, 1007, 1022, 1032, 1044, 1060, 1076, 1079, 1085, 1090, 1093, 1102, 1111, 1121, 1139, 1154, 1162, 1172, 1180, 1188, 1200, 1217, 1235, 1252, 1275, 1289, 1298, 1303, 1314, 1327, 1341, 1350, 1361, 1370, 1380, 1390, 1401, 1410, 1422, 1431, 1438, 1449, 1457, 1477, 1499, 1518, 1540, 1564, 1586, 1608, 1641, 1649, 1661, 1669, 1678, 1684, 1692, 1699, 1709, 1723, 1738, 1746, 1753, 1761, 1766, 1797, 1815, 1822, 1832}
+var _TokenType_index = [...]uint16{0, 9, 16, 25, 33, 38, 40, 45, 53, 57, 62, 67, 80, 85, 90, 98, 105, 112, 114, 118, 122, 128, 133, 143, 149, 159, 164, 173, 181, 191, 195, 199, 206, 211, 218, 221, 228, 236, 238, 244, 248, 260, 262, 272, 279, 290, 300, 309, 318, 323, 327, 330, 332, 335, 344, 347, 352, 359, 365, 374, 381, 392, 398, 404, 410, 415, 420, 423, 428, 431, 434, 439, 444, 453, 470, 482, 494, 510, 524, 541, 554, 567, 582, 607, 611, 625, 629, 641, 647, 652, 657, 666, 677, 683, 695, 702, 707, 715, 723, 731, 742, 753, 761, 772, 780, 798, 807, 822, 833, 849, 871, 893, 921, 930, 934, 944, 960, 982, 987, 996, 1007, 1022, 1032, 1044, 1060, 1076, 1098, 1101, 1107, 1112, 1115, 1124, 1133, 1143, 1161, 1176, 1184, 1194, 1202, 1210, 1222, 1239, 1257, 1274, 1297, 1311, 1320, 1325, 1336, 1349, 1363, 1372, 1383, 1392, 1402, 1412, 1423, 1432, 1444, 1453, 1460, 1471, 1479, 1499, 1521, 1540, 1562, 1586, 1608, 1630, 1663, 1671, 1683, 1691, 1700, 1706, 1714, 1721, 1731, 1745, 1760, 1768, 1775, 1783, 1788, 1798, 1817, 1840, 1849, 1861, 1866, 1873, 1883, 1891, 1905, 1920, 1925, 1937, 1968, 1986, 1993, 2003, 2019}
 
 func (i TokenType) String() string {
 	if i >= TokenType(len(_TokenType_index)-1) {
@@ -0,0 +1,23 @@
+package lsp
+
+import "github.com/john-nguyen09/go-phpparser/ranges"
+
+// foldingKinds are the LSP kinds of ranges.FoldKind
+var foldingKinds = [...]string{
+	ranges.Block:   "",
+	ranges.Comment: FoldingRangeComment,
+	ranges.Imports: FoldingRangeImports,
+	ranges.Region:  FoldingRangeRegion,
+}
+
+func foldingRanges(doc *document) []FoldingRange {
+	result := []FoldingRange{}
+	for _, fold := range ranges.Folding(doc.root, doc.source, doc.index) {
+		result = append(result, FoldingRange{
+			StartLine: fold.StartLine,
+			EndLine:   fold.EndLine,
+			Kind:      foldingKinds[fold.Kind],
+		})
+	}
+	return result
+}
@@ -0,0 +1,18 @@
+package lsp
+
+import "github.com/john-nguyen09/go-phpparser/ranges"
+
+// selectionRange returns the innermost token at offset with every enclosing
+// phrase as its parents
+func selectionRange(doc *document, offset int) SelectionRange {
+	var result *SelectionRange
+	spans := ranges.Selection(doc.root, offset)
+	for i := len(spans) - 1; i >= 0; i-- {
+		result = &SelectionRange{Range: doc.rangeOf(spans[i].Start, spans[i].End), Parent: result}
+	}
+	if result == nil {
+		pos := doc.index.Position(offset)
+		return SelectionRange{Range: Range{pos, pos}}
+	}
+	return *result
+}
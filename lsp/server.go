@@ -0,0 +1,349 @@
+// Package lsp implements a Language Server Protocol server for PHP on top of
+// parser.Parse, speaking JSON-RPC over any reader and writer.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"github.com/john-nguyen09/go-phpparser/highlight"
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+	"github.com/john-nguyen09/go-phpparser/position"
+)
+
+// ErrExitWithoutShutdown is returned by Run when the client sends exit before
+// shutdown, in which case the server should exit with code 1
+var ErrExitWithoutShutdown = errors.New("exit notification received before shutdown")
+
+type document struct {
+	uri     string
+	version int
+	source  []byte
+	root    *phrase.Phrase
+	// lexical are the lexical errors of the source
+	lexical []lexer.Diagnostic
+	index   *position.Index
+}
+
+func newDocument(uri string, version int, source []byte) *document {
+	root, lexical := parser.ParseWithDiagnostics(source, parser.Options{})
+	return &document{
+		uri:     uri,
+		version: version,
+		source:  source,
+		root:    root,
+		lexical: lexical,
+		index:   position.NewIndex(source),
+	}
+}
+
+func (d *document) rangeOf(start, end int) Range {
+	return Range{d.index.Position(start), d.index.Position(end)}
+}
+
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+var handlers = map[string]handler{
+	"initialize":                       (*Server).initialize,
+	"shutdown":                         (*Server).shutdown,
+	"textDocument/documentSymbol":      (*Server).documentSymbol,
+	"textDocument/foldingRange":        (*Server).foldingRange,
+	"textDocument/selectionRange":      (*Server).selectionRange,
+	"textDocument/semanticTokens/full": (*Server).semanticTokensFull,
+}
+
+var notificationHandlers = map[string]func(s *Server, params json.RawMessage) error{
+	"textDocument/didOpen":   (*Server).didOpen,
+	"textDocument/didChange": (*Server).didChange,
+	"textDocument/didClose":  (*Server).didClose,
+}
+
+// Server handles one client connection. Messages are processed in order, one
+// at a time.
+type Server struct {
+	conn        *conn
+	documents   map[string]*document
+	initialized bool
+	shutdownReq bool
+}
+
+func NewServer(r io.Reader, w io.Writer) *Server {
+	return &Server{
+		conn:      newConn(r, w),
+		documents: map[string]*document{},
+	}
+}
+
+// Run serves requests until the client sends exit or closes the input. It
+// returns nil on a clean shutdown and exit.
+func (s *Server) Run() error {
+	for {
+		msg, err := s.conn.read()
+		if respErr, ok := err.(*responseError); ok {
+			if err := s.conn.reply(nil, nil, respErr); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdownReq {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+		if msg.ID == nil {
+			if err := s.handleNotification(msg); err != nil {
+				return err
+			}
+			continue
+		}
+		result, err := s.handleRequest(msg)
+		if err := s.conn.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handleRequest(msg *message) (interface{}, error) {
+	h, ok := handlers[msg.Method]
+	if !ok {
+		return nil, &responseError{codeMethodNotFound, "method not found: " + msg.Method}
+	}
+	if !s.initialized && msg.Method != "initialize" {
+		return nil, &responseError{codeServerNotInitialized, "server not initialized"}
+	}
+	if s.shutdownReq {
+		return nil, &responseError{codeInvalidRequest, "server is shutting down"}
+	}
+	return h(s, msg.Params)
+}
+
+// handleNotification ignores unknown notifications such as initialized and
+// $/cancelRequest, as the protocol requires
+func (s *Server) handleNotification(msg *message) error {
+	h, ok := notificationHandlers[msg.Method]
+	if !ok || !s.initialized {
+		return nil
+	}
+	return h(s, msg.Params)
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	s.initialized = true
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync: TextDocumentSyncOptions{
+				OpenClose: true,
+				Change:    SyncIncremental,
+			},
+			DocumentSymbolProvider: true,
+			FoldingRangeProvider:   true,
+			SelectionRangeProvider: true,
+			SemanticTokensProvider: SemanticTokensOptions{
+				Legend: SemanticTokensLegend{
+					TokenTypes:     highlight.TokenTypes,
+					TokenModifiers: highlight.TokenModifiers,
+				},
+				Full: true,
+			},
+		},
+		ServerInfo: ServerInfo{Name: "phplsp"},
+	}, nil
+}
+
+func (s *Server) shutdown(params json.RawMessage) (interface{}, error) {
+	s.shutdownReq = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) error {
+	var p DidOpenTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	doc := newDocument(p.TextDocument.URI, p.TextDocument.Version, []byte(p.TextDocument.Text))
+	s.documents[doc.uri] = doc
+	return s.publishDiagnostics(doc)
+}
+
+func (s *Server) didChange(params json.RawMessage) error {
+	var p DidChangeTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	source := doc.source
+	index := doc.index
+	for _, change := range p.ContentChanges {
+		if change.Range == nil {
+			source = []byte(change.Text)
+		} else {
+			start, end := index.Offset(change.Range.Start), index.Offset(change.Range.End)
+			if end < start {
+				start, end = end, start
+			}
+			edited := make([]byte, 0, len(source)-(end-start)+len(change.Text))
+			edited = append(edited, source[:start]...)
+			edited = append(edited, change.Text...)
+			source = append(edited, source[end:]...)
+		}
+		index = position.NewIndex(source)
+	}
+	doc = newDocument(doc.uri, p.TextDocument.Version, source)
+	s.documents[doc.uri] = doc
+	return s.publishDiagnostics(doc)
+}
+
+func (s *Server) didClose(params json.RawMessage) error {
+	var p DidCloseTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	if _, ok := s.documents[p.TextDocument.URI]; !ok {
+		return nil
+	}
+	delete(s.documents, p.TextDocument.URI)
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.documents[uri]
+	if !ok {
+		return nil, &responseError{codeInvalidParams, "unknown document: " + uri}
+	}
+	return doc, nil
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p DocumentSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &responseError{codeInvalidParams, err.Error()}
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return documentSymbols(doc), nil
+}
+
+func (s *Server) foldingRange(params json.RawMessage) (interface{}, error) {
+	var p FoldingRangeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &responseError{codeInvalidParams, err.Error()}
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return foldingRanges(doc), nil
+}
+
+func (s *Server) selectionRange(params json.RawMessage) (interface{}, error) {
+	var p SelectionRangeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &responseError{codeInvalidParams, err.Error()}
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	ranges := []SelectionRange{}
+	for _, pos := range p.Positions {
+		ranges = append(ranges, selectionRange(doc, doc.index.Offset(pos)))
+	}
+	return ranges, nil
+}
+
+func (s *Server) semanticTokensFull(params json.RawMessage) (interface{}, error) {
+	var p SemanticTokensParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &responseError{codeInvalidParams, err.Error()}
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return SemanticTokens{Data: highlight.Encode(highlight.Classify(doc.root, doc.source), doc.index)}, nil
+}
+
+func (s *Server) publishDiagnostics(doc *document) error {
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: diagnostics(doc),
+	})
+}
+
+// diagnostics reports every lexical error over the text it spans and every
+// parse error at its unexpected token
+func diagnostics(doc *document) []Diagnostic {
+	result := []Diagnostic{}
+	for _, d := range doc.lexical {
+		severity := SeverityError
+		if d.Kind.IsWarning() {
+			severity = SeverityWarning
+		}
+		result = append(result, Diagnostic{
+			Range:    doc.rangeOf(d.Offset, d.Offset+d.Length),
+			Severity: severity,
+			Source:   "phplsp",
+			Message:  d.Message(),
+		})
+	}
+	var walk func(node phrase.AstNode)
+	walk = func(node phrase.AstNode) {
+		var children []phrase.AstNode
+		switch node := node.(type) {
+		case *phrase.Phrase:
+			children = node.Children
+		case *phrase.ParseError:
+			children = node.Children
+			result = append(result, parseErrorDiagnostic(doc, node))
+		}
+		for _, child := range children {
+			walk(child)
+		}
+	}
+	walk(doc.root)
+	return result
+}
+
+func parseErrorDiagnostic(doc *document, err *phrase.ParseError) Diagnostic {
+	t := err.Unexpected
+	start, end := len(doc.source), len(doc.source)
+	if t != nil && t.Offset < len(doc.source) {
+		start, end = t.Offset, t.Offset+t.Length
+	}
+
+	message := "Unexpected end of file"
+	if start < end {
+		text := string(doc.source[start:end])
+		if utf8.RuneCountInString(text) > 40 {
+			text = string([]rune(text)[:40]) + "..."
+		}
+		message = "Unexpected '" + text + "'"
+	}
+	if err.Expected != lexer.Undefined {
+		message += ", expected " + err.Expected.String()
+	}
+	return Diagnostic{
+		Range:    doc.rangeOf(start, end),
+		Severity: SeverityError,
+		Source:   "phplsp",
+		Message:  message,
+	}
+}
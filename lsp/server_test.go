@@ -0,0 +1,394 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/john-nguyen09/go-phpparser/position"
+)
+
+// testClient drives a Server running in-process over pipes
+type testClient struct {
+	t        *testing.T
+	conn     *conn
+	nextID   int
+	messages chan *message
+	pending  []*message
+	done     chan error
+}
+
+func newTestClient(t *testing.T) *testClient {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	c := &testClient{
+		t:        t,
+		conn:     newConn(clientReader, clientWriter),
+		messages: make(chan *message),
+		done:     make(chan error, 1),
+	}
+	go func() {
+		c.done <- NewServer(serverReader, serverWriter).Run()
+		serverWriter.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		for {
+			msg, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			c.messages <- msg
+		}
+	}()
+	return c
+}
+
+func (c *testClient) receive() *message {
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("server closed the connection")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+	}
+	return nil
+}
+
+// call sends a request and decodes the result of its response, queueing any
+// notification received meanwhile
+func (c *testClient) call(method string, params interface{}, result interface{}) *responseError {
+	c.nextID++
+	raw, _ := json.Marshal(params)
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	if err := c.conn.write(&message{ID: &id, Method: method, Params: raw}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		msg := c.receive()
+		if msg.ID == nil {
+			c.pending = append(c.pending, msg)
+			continue
+		}
+		if string(*msg.ID) != string(id) {
+			c.t.Fatalf("unexpected response id %s", *msg.ID)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	raw, _ := json.Marshal(params)
+	if err := c.conn.write(&message{Method: method, Params: raw}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// notification waits for the next notification of method
+func (c *testClient) notification(method string, params interface{}) {
+	for {
+		var msg *message
+		if len(c.pending) > 0 {
+			msg, c.pending = c.pending[0], c.pending[1:]
+		} else {
+			msg = c.receive()
+		}
+		if msg.Method == method {
+			if err := json.Unmarshal(msg.Params, params); err != nil {
+				c.t.Fatal(err)
+			}
+			return
+		}
+	}
+}
+
+func (c *testClient) initialize() InitializeResult {
+	var result InitializeResult
+	if err := c.call("initialize", InitializeParams{}, &result); err != nil {
+		c.t.Fatal(err)
+	}
+	c.notify("initialized", struct{}{})
+	return result
+}
+
+func (c *testClient) open(uri, text string) PublishDiagnosticsParams {
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "php", Version: 1, Text: text},
+	})
+	var diagnostics PublishDiagnosticsParams
+	c.notification("textDocument/publishDiagnostics", &diagnostics)
+	return diagnostics
+}
+
+func (c *testClient) shutdown() error {
+	if err := c.call("shutdown", nil, nil); err != nil {
+		c.t.Fatal(err)
+	}
+	c.notify("exit", nil)
+	select {
+	case err := <-c.done:
+		return err
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("server did not exit")
+	}
+	return nil
+}
+
+func pos(line, character int) position.Position {
+	return position.Position{Line: line, Character: character}
+}
+
+func TestLifecycle(t *testing.T) {
+	c := newTestClient(t)
+	if err := c.call("shutdown", nil, nil); err == nil || err.Code != codeServerNotInitialized {
+		t.Errorf("expected a server not initialized error, got %v", err)
+	}
+
+	result := c.initialize()
+	if result.Capabilities.TextDocumentSync.Change != SyncIncremental ||
+		!result.Capabilities.DocumentSymbolProvider ||
+		!result.Capabilities.SemanticTokensProvider.Full {
+		t.Errorf("unexpected capabilities %+v", result.Capabilities)
+	}
+	if err := c.call("textDocument/hover", struct{}{}, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("expected a method not found error, got %v", err)
+	}
+	if err := c.shutdown(); err != nil {
+		t.Errorf("expected a clean exit, got %v", err)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+	c.notify("exit", nil)
+	if err := <-c.done; err != ErrExitWithoutShutdown {
+		t.Errorf("expected ErrExitWithoutShutdown, got %v", err)
+	}
+}
+
+func TestParseErrorResponse(t *testing.T) {
+	var out bytes.Buffer
+	err := NewServer(strings.NewReader("Content-Length: 5\r\n\r\n{bad}"), &out).Run()
+	if err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if !strings.Contains(out.String(), `"id":null`) || !strings.Contains(out.String(), `"code":-32700`) {
+		t.Errorf("expected a parse error with a null id, got %q", out.String())
+	}
+}
+
+func TestInvalidHeader(t *testing.T) {
+	var out bytes.Buffer
+	in := "Content-Length: x\r\n\r\n" +
+		"bad header\r\nContent-Length: 2\r\n\r\n" +
+		"Content-Length: 44\r\n\r\n" + `{"jsonrpc":"2.0","id":1,"method":"shutdown"}`
+	err := NewServer(strings.NewReader(in), &out).Run()
+	if err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if n := strings.Count(out.String(), `"code":-32700`); n != 2 {
+		t.Errorf("expected 2 parse errors, got %d in %q", n, out.String())
+	}
+	if !strings.Contains(out.String(), `"id":1,"error":{"code":-32002`) {
+		t.Errorf("expected the request after the invalid headers to be served, got %q", out.String())
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+
+	diagnostics := c.open("file:///a.php", "<?php\n$a = ;\n")
+	if len(diagnostics.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %+v", diagnostics.Diagnostics)
+	}
+	d := diagnostics.Diagnostics[0]
+	if d.Range != (Range{pos(1, 5), pos(1, 6)}) || d.Message != "Unexpected ';'" || d.Severity != SeverityError {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: VersionedTextDocumentIdentifier{URI: "file:///a.php", Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{
+			{Range: &Range{pos(1, 5), pos(1, 5)}, Text: "1"},
+		},
+	})
+	c.notification("textDocument/publishDiagnostics", &diagnostics)
+	if diagnostics.Version != 2 || len(diagnostics.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics after the edit, got %+v", diagnostics)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: "file:///a.php", Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "<?php\nclass {}\n"}},
+	})
+	c.notification("textDocument/publishDiagnostics", &diagnostics)
+	if len(diagnostics.Diagnostics) != 1 {
+		t.Errorf("expected a diagnostic after replacing the document, got %+v", diagnostics)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: "file:///a.php", Version: 4},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "<?php\n$a = 1;\n/* note\n"}},
+	})
+	c.notification("textDocument/publishDiagnostics", &diagnostics)
+	if len(diagnostics.Diagnostics) != 1 {
+		t.Fatalf("expected an unterminated comment, got %+v", diagnostics)
+	}
+	d = diagnostics.Diagnostics[0]
+	if d.Range != (Range{pos(2, 0), pos(3, 0)}) || d.Message != "Unterminated comment" {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///a.php"},
+	})
+	c.notification("textDocument/publishDiagnostics", &diagnostics)
+	if len(diagnostics.Diagnostics) != 0 {
+		t.Errorf("expected diagnostics to be cleared on close, got %+v", diagnostics)
+	}
+	if err := c.call("textDocument/semanticTokens/full", SemanticTokensParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///a.php"},
+	}, nil); err == nil || err.Code != codeInvalidParams {
+		t.Errorf("expected closed documents to be unknown, got %v", err)
+	}
+
+	diagnostics = c.open("file:///b.php", "\uFEFF<?php\n$a = 1;\n")
+	if len(diagnostics.Diagnostics) != 1 || diagnostics.Diagnostics[0].Severity != SeverityWarning ||
+		diagnostics.Diagnostics[0].Range != (Range{pos(0, 0), pos(0, 1)}) {
+		t.Errorf("expected a warning for the byte order mark, got %+v", diagnostics.Diagnostics)
+	}
+	c.shutdown()
+}
+
+const featureSource = `<?php
+/**
+ * A class
+ */
+class Foo
+{
+    const BAR = 1;
+    public $baz = [
+        1,
+    ];
+
+    public function qux($x)
+    {
+        return $x + 1;
+    }
+}
+
+function quux() {}
+/* multi
+   line */
+`
+
+func TestFeatures(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+	c.open("file:///foo.php", featureSource)
+	document := TextDocumentIdentifier{URI: "file:///foo.php"}
+
+	var symbols []DocumentSymbol
+	if err := c.call("textDocument/documentSymbol", DocumentSymbolParams{document}, &symbols); err != nil {
+		t.Fatal(err)
+	}
+	expectedSymbols := []DocumentSymbol{
+		{Name: "Foo", Detail: "class Foo", Kind: SymbolClass, Range: Range{pos(4, 0), pos(15, 1)}, SelectionRange: Range{pos(4, 6), pos(4, 9)},
+			Children: []DocumentSymbol{
+				{Name: "BAR", Detail: "1", Kind: SymbolConstant, Range: Range{pos(6, 10), pos(6, 17)}, SelectionRange: Range{pos(6, 10), pos(6, 13)}},
+				{Name: "$baz", Detail: "public", Kind: SymbolProperty, Range: Range{pos(7, 11), pos(9, 5)}, SelectionRange: Range{pos(7, 11), pos(7, 15)}},
+				{Name: "qux", Detail: "public function qux($x)", Kind: SymbolMethod, Range: Range{pos(11, 4), pos(14, 5)}, SelectionRange: Range{pos(11, 20), pos(11, 23)}},
+			}},
+		{Name: "quux", Detail: "function quux()", Kind: SymbolFunction, Range: Range{pos(17, 0), pos(17, 18)}, SelectionRange: Range{pos(17, 9), pos(17, 13)}},
+	}
+	if !reflect.DeepEqual(symbols, expectedSymbols) {
+		t.Errorf("unexpected symbols %+v", symbols)
+	}
+
+	var folding []FoldingRange
+	if err := c.call("textDocument/foldingRange", FoldingRangeParams{document}, &folding); err != nil {
+		t.Fatal(err)
+	}
+	expectedFolding := []FoldingRange{
+		{StartLine: 1, EndLine: 3, Kind: FoldingRangeComment},
+		{StartLine: 5, EndLine: 14},
+		{StartLine: 7, EndLine: 8},
+		{StartLine: 12, EndLine: 13},
+		{StartLine: 18, EndLine: 19, Kind: FoldingRangeComment},
+	}
+	if !reflect.DeepEqual(folding, expectedFolding) {
+		t.Errorf("unexpected folding ranges %+v", folding)
+	}
+
+	var selection []SelectionRange
+	if err := c.call("textDocument/selectionRange", SelectionRangeParams{
+		TextDocument: document,
+		Positions:    []position.Position{pos(13, 16)},
+	}, &selection); err != nil {
+		t.Fatal(err)
+	}
+	expectedSelection := []Range{
+		{pos(13, 15), pos(13, 17)}, // $x
+		{pos(13, 15), pos(13, 21)}, // $x + 1
+		{pos(13, 8), pos(13, 22)},  // return $x + 1;
+	}
+	r := &selection[0]
+	for i, expected := range expectedSelection {
+		if r == nil || r.Range != expected {
+			t.Fatalf("selection range %d: expected %+v, got %+v", i, expected, r)
+		}
+		r = r.Parent
+	}
+
+	var tokens SemanticTokens
+	if err := c.call("textDocument/semanticTokens/full", SemanticTokensParams{document}, &tokens); err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens.Data)%5 != 0 {
+		t.Fatalf("expected 5 integers per token, got %d", len(tokens.Data))
+	}
+	decoded := map[[4]uint32]bool{}
+	var line, character uint32
+	for i := 0; i < len(tokens.Data); i += 5 {
+		if tokens.Data[i] > 0 {
+			character = 0
+		}
+		line += tokens.Data[i]
+		character += tokens.Data[i+1]
+		decoded[[4]uint32{line, character, tokens.Data[i+2], tokens.Data[i+3]}] = true
+	}
+	for _, expected := range [][4]uint32{
+		{1, 0, 3, 10},   // /** comment
+		{4, 0, 5, 0},    // class keyword
+		{4, 6, 3, 2},    // Foo class
+		{6, 16, 1, 9},   // 1 number
+		{7, 11, 4, 5},   // $baz property
+		{11, 20, 3, 4},  // qux method
+		{13, 15, 2, 6},  // $x parameter
+		{13, 18, 1, 13}, // + operator
+		{18, 0, 8, 10},  // the comment is split per line
+		{19, 0, 10, 10},
+	} {
+		if !decoded[expected] {
+			t.Errorf("expected semantic token %v in %v", expected, decoded)
+		}
+	}
+	c.shutdown()
+}
@@ -0,0 +1,39 @@
+package lsp
+
+import "github.com/john-nguyen09/go-phpparser/outline"
+
+// symbolKinds are the LSP kinds of outline.Kind
+var symbolKinds = [...]SymbolKind{
+	outline.Namespace:   SymbolNamespace,
+	outline.Class:       SymbolClass,
+	outline.Interface:   SymbolInterface,
+	outline.Trait:       SymbolClass,
+	outline.Function:    SymbolFunction,
+	outline.Method:      SymbolMethod,
+	outline.Constructor: SymbolConstructor,
+	outline.Property:    SymbolProperty,
+	outline.Constant:    SymbolConstant,
+	outline.Closure:     SymbolFunction,
+}
+
+func documentSymbols(doc *document) []DocumentSymbol {
+	return doc.symbols(outline.Outline(doc.root, doc.source))
+}
+
+func (d *document) symbols(symbols []outline.Symbol) []DocumentSymbol {
+	result := []DocumentSymbol{}
+	for _, s := range symbols {
+		symbol := DocumentSymbol{
+			Name:           s.Name,
+			Detail:         s.Detail,
+			Kind:           symbolKinds[s.Kind],
+			Range:          d.rangeOf(s.Range.Start, s.Range.End),
+			SelectionRange: d.rangeOf(s.SelectionRange.Start, s.SelectionRange.End),
+		}
+		if len(s.Children) > 0 {
+			symbol.Children = d.symbols(s.Children)
+		}
+		result = append(result, symbol)
+	}
+	return result
+}
@@ -0,0 +1,32 @@
+// Code generated by "stringer -type=Kind"; DO NOT EDIT.
+
+package outline
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Namespace-0]
+	_ = x[Class-1]
+	_ = x[Interface-2]
+	_ = x[Trait-3]
+	_ = x[Function-4]
+	_ = x[Method-5]
+	_ = x[Constructor-6]
+	_ = x[Property-7]
+	_ = x[Constant-8]
+	_ = x[Closure-9]
+}
+
+const _Kind_name = "NamespaceClassInterfaceTraitFunctionMethodConstructorPropertyConstantClosure"
+
+var _Kind_index = [...]uint8{0, 9, 14, 23, 28, 36, 42, 53, 61, 69, 76}
+
+func (i Kind) String() string {
+	if i >= Kind(len(_Kind_index)-1) {
+		return "Kind(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Kind_name[_Kind_index[i]:_Kind_index[i+1]]
+}
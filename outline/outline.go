@@ -0,0 +1,401 @@
+// Package outline builds the hierarchical outline of a document: namespaces
+// containing classes, interfaces and traits containing their members, along
+// with functions, constants, closures assigned to variables and anonymous
+// classes.
+package outline
+
+import (
+	"strings"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+	"github.com/john-nguyen09/go-phpparser/ranges"
+)
+
+type Kind uint8
+
+const (
+	Namespace Kind = iota
+	Class
+	Interface
+	Trait
+	Function
+	Method
+	Constructor
+	Property
+	Constant
+	Closure
+)
+
+//go:generate stringer -type=Kind
+
+// AnonymousClass is the name of anonymous classes
+const AnonymousClass = "class@anonymous"
+
+// Symbol is an entry of the outline. Detail is its signature with whitespace
+// and comments collapsed to single spaces: the header of classes and
+// functions, the modifiers and type of properties, the value of constants.
+// Range covers the whole declaration and SelectionRange its name.
+type Symbol struct {
+	Name           string
+	Kind           Kind
+	Detail         string
+	Range          ranges.Span
+	SelectionRange ranges.Span
+	Children       []Symbol
+}
+
+type outliner struct {
+	source []byte
+}
+
+// Outline returns the top level symbols of the tree
+func Outline(root *phrase.Phrase, source []byte) []Symbol {
+	o := &outliner{source: source}
+	return o.symbols(root)
+}
+
+// symbols returns the symbols found in the children of p. Statements following
+// a namespace definition without braces belong to that namespace.
+func (o *outliner) symbols(p *phrase.Phrase) []Symbol {
+	result := []Symbol{}
+	var namespace *Symbol
+	for _, child := range p.Children {
+		if child, ok := child.(*phrase.Phrase); ok && child.Type == phrase.NamespaceDefinition &&
+			childPhrase(child, phrase.StatementList) == nil {
+			if namespace != nil {
+				result = append(result, *namespace)
+			}
+			namespace = o.namespace(child)
+			continue
+		}
+		symbols := o.node(child)
+		if namespace == nil {
+			result = append(result, symbols...)
+			continue
+		}
+		namespace.Children = append(namespace.Children, symbols...)
+		if last := phrase.LastToken(child); last != nil {
+			namespace.Range.End = last.Offset + last.Length
+		}
+	}
+	if namespace != nil {
+		result = append(result, *namespace)
+	}
+	return result
+}
+
+func (o *outliner) node(node phrase.AstNode) []Symbol {
+	p, ok := node.(*phrase.Phrase)
+	if !ok {
+		if err, ok := node.(*phrase.ParseError); ok {
+			return o.symbols(&err.Phrase)
+		}
+		return nil
+	}
+
+	switch p.Type {
+	case phrase.NamespaceDefinition:
+		return []Symbol{*o.namespace(p)}
+	case phrase.ClassDeclaration, phrase.InterfaceDeclaration, phrase.TraitDeclaration:
+		kind := Class
+		switch p.Type {
+		case phrase.InterfaceDeclaration:
+			kind = Interface
+		case phrase.TraitDeclaration:
+			kind = Trait
+		}
+		header := firstPhrase(p)
+		name := childToken(header, lexer.Name)
+		if name == nil {
+			break
+		}
+		symbol := o.symbol(p, name, kind, o.signature(header))
+		symbol.Children = o.members(p)
+		return []Symbol{symbol}
+	case phrase.AnonymousClassDeclaration:
+		header := firstPhrase(p)
+		symbol := o.symbol(p, childToken(header, lexer.Class), Class, o.signature(header))
+		symbol.Name = AnonymousClass
+		symbol.Children = o.members(p)
+		return []Symbol{symbol}
+	case phrase.FunctionDeclaration:
+		header := firstPhrase(p)
+		name := childToken(header, lexer.Name)
+		if name == nil {
+			break
+		}
+		symbol := o.symbol(p, name, Function, o.signature(header))
+		symbol.Children = o.symbols(p)
+		return []Symbol{symbol}
+	case phrase.ConstDeclaration:
+		return o.elements(p, phrase.ConstElement, Constant)
+	case phrase.SimpleAssignmentExpression:
+		if symbol, ok := o.closure(p); ok {
+			return []Symbol{symbol}
+		}
+	case phrase.FunctionCallExpression:
+		if symbol, ok := o.define(p); ok {
+			return []Symbol{symbol}
+		}
+	}
+	return o.symbols(p)
+}
+
+func (o *outliner) namespace(p *phrase.Phrase) *Symbol {
+	name := childPhrase(p, phrase.NamespaceName)
+	symbol := &Symbol{Kind: Namespace, Range: span(p)}
+	if name != nil {
+		symbol.Name = o.text(name)
+		symbol.SelectionRange = span(name)
+	} else {
+		// namespace { } declares code in the global namespace
+		symbol.SelectionRange = span(phrase.FirstToken(p))
+	}
+	if list := childPhrase(p, phrase.StatementList); list != nil {
+		symbol.Children = o.symbols(list)
+	}
+	return symbol
+}
+
+// members returns the members declared in the body of a class, interface or
+// trait
+func (o *outliner) members(p *phrase.Phrase) []Symbol {
+	result := []Symbol{}
+	for _, body := range p.Children[1:] {
+		body, ok := body.(*phrase.Phrase)
+		if !ok {
+			continue
+		}
+		for _, list := range body.Children {
+			list, ok := list.(*phrase.Phrase)
+			if !ok {
+				continue
+			}
+			for _, member := range list.Children {
+				member, ok := member.(*phrase.Phrase)
+				if !ok {
+					continue
+				}
+				result = append(result, o.member(member)...)
+			}
+		}
+	}
+	return result
+}
+
+func (o *outliner) member(p *phrase.Phrase) []Symbol {
+	switch p.Type {
+	case phrase.MethodDeclaration:
+		header := firstPhrase(p)
+		name := childPhrase(header, phrase.Identifier)
+		if name == nil {
+			return nil
+		}
+		kind := Method
+		if strings.EqualFold(o.text(name), "__construct") {
+			kind = Constructor
+		}
+		symbol := o.symbol(p, name, kind, o.signature(header))
+		symbol.Children = o.symbols(p)
+		return []Symbol{symbol}
+	case phrase.PropertyDeclaration:
+		var modifiers []phrase.AstNode
+		for _, child := range p.Children {
+			if child, ok := child.(*phrase.Phrase); ok && child.Type == phrase.PropertyElementList {
+				break
+			}
+			modifiers = append(modifiers, child)
+		}
+		symbols := o.elements(p, phrase.PropertyElement, Property)
+		for i := range symbols {
+			symbols[i].Detail = o.signature(modifiers...)
+		}
+		return symbols
+	case phrase.ClassConstDeclaration:
+		return o.elements(p, phrase.ClassConstElement, Constant)
+	}
+	return nil
+}
+
+// elements returns a symbol for each element of a declaration such as
+// const A = 1, B = 2, the detail being the value
+func (o *outliner) elements(p *phrase.Phrase, elementType phrase.PhraseType, kind Kind) []Symbol {
+	result := []Symbol{}
+	for _, list := range p.Children {
+		list, ok := list.(*phrase.Phrase)
+		if !ok {
+			continue
+		}
+		for _, element := range list.Children {
+			element, ok := element.(*phrase.Phrase)
+			if !ok || element.Type != elementType || len(element.Children) == 0 {
+				continue
+			}
+			var value []phrase.AstNode
+			for i, child := range element.Children {
+				if t, ok := child.(*lexer.Token); ok && t.Type == lexer.Equals {
+					value = element.Children[i+1:]
+					break
+				}
+			}
+			result = append(result, o.symbol(element, element.Children[0], kind, o.signature(value...)))
+		}
+	}
+	return result
+}
+
+// closure returns the symbol of $name = function () {} or $name = fn() => 1
+func (o *outliner) closure(p *phrase.Phrase) (Symbol, bool) {
+	if len(p.Children) == 0 {
+		return Symbol{}, false
+	}
+	variable, ok := p.Children[0].(*phrase.Phrase)
+	if !ok || variable.Type != phrase.SimpleVariable {
+		return Symbol{}, false
+	}
+	for _, child := range p.Children[1:] {
+		child, ok := child.(*phrase.Phrase)
+		if !ok {
+			continue
+		}
+		switch child.Type {
+		case phrase.AnonymousFunctionCreationExpression, phrase.ArrowFunctionCreationExpression:
+			symbol := o.symbol(p, variable, Closure, o.signature(firstPhrase(child)))
+			symbol.Children = o.symbols(child)
+			return symbol, true
+		}
+		return Symbol{}, false
+	}
+	return Symbol{}, false
+}
+
+// define returns the symbol of define('NAME', value)
+func (o *outliner) define(p *phrase.Phrase) (Symbol, bool) {
+	name := firstPhrase(p)
+	if name.Type != phrase.QualifiedName && name.Type != phrase.FullyQualifiedName {
+		return Symbol{}, false
+	}
+	if name = childPhrase(name, phrase.NamespaceName); name == nil || !strings.EqualFold(o.text(name), "define") {
+		return Symbol{}, false
+	}
+	arguments := childPhrase(p, phrase.ArgumentExpressionList)
+	if arguments == nil {
+		return Symbol{}, false
+	}
+	var args [][]phrase.AstNode
+	var arg []phrase.AstNode
+	for _, child := range arguments.Children {
+		if t, ok := child.(*lexer.Token); ok {
+			switch t.Type {
+			case lexer.OpenParenthesis, lexer.Whitespace, lexer.Comment:
+				continue
+			case lexer.Comma, lexer.CloseParenthesis:
+				args = append(args, arg)
+				arg = nil
+				continue
+			}
+		}
+		arg = append(arg, child)
+	}
+	if len(args) < 2 || len(args[0]) != 1 {
+		return Symbol{}, false
+	}
+	constant, ok := args[0][0].(*lexer.Token)
+	if !ok || constant.Type != lexer.StringLiteral || constant.Length < 2 {
+		return Symbol{}, false
+	}
+	symbol := o.symbol(p, constant, Constant, o.signature(args[1]...))
+	symbol.Name = o.text(constant)
+	symbol.Name = symbol.Name[1 : len(symbol.Name)-1]
+	return symbol, true
+}
+
+func (o *outliner) symbol(node phrase.AstNode, name phrase.AstNode, kind Kind, detail string) Symbol {
+	return Symbol{
+		Name:           o.text(name),
+		Kind:           kind,
+		Detail:         detail,
+		Range:          span(node),
+		SelectionRange: span(name),
+	}
+}
+
+// signature returns the text of nodes with whitespace and comments between
+// tokens collapsed to a single space
+func (o *outliner) signature(nodes ...phrase.AstNode) string {
+	var b strings.Builder
+	end := -1
+	var visit func(node phrase.AstNode)
+	visit = func(node phrase.AstNode) {
+		switch node := node.(type) {
+		case *lexer.Token:
+			if node.Type == lexer.Comment || node.Type == lexer.Whitespace {
+				return
+			}
+			if end >= 0 && end < node.Offset {
+				b.WriteByte(' ')
+			}
+			b.Write(o.source[node.Offset : node.Offset+node.Length])
+			end = node.Offset + node.Length
+		case *phrase.Phrase:
+			if node.Type == phrase.DocumentComment {
+				return
+			}
+			for _, child := range node.Children {
+				visit(child)
+			}
+		case *phrase.ParseError:
+			for _, child := range node.Children {
+				visit(child)
+			}
+		}
+	}
+	for _, node := range nodes {
+		visit(node)
+	}
+	return b.String()
+}
+
+func (o *outliner) text(node phrase.AstNode) string {
+	first, last := phrase.FirstToken(node), phrase.LastToken(node)
+	if first == nil {
+		return ""
+	}
+	return string(o.source[first.Offset : last.Offset+last.Length])
+}
+
+func span(node phrase.AstNode) ranges.Span {
+	first, last := phrase.FirstToken(node), phrase.LastToken(node)
+	if first == nil {
+		return ranges.Span{}
+	}
+	return ranges.Span{Start: first.Offset, End: last.Offset + last.Length}
+}
+
+func firstPhrase(p *phrase.Phrase) *phrase.Phrase {
+	for _, child := range p.Children {
+		if child, ok := child.(*phrase.Phrase); ok {
+			return child
+		}
+	}
+	return &phrase.Phrase{}
+}
+
+func childPhrase(p *phrase.Phrase, phraseType phrase.PhraseType) *phrase.Phrase {
+	for _, child := range p.Children {
+		if child, ok := child.(*phrase.Phrase); ok && child.Type == phraseType {
+			return child
+		}
+	}
+	return nil
+}
+
+func childToken(p *phrase.Phrase, tokenType lexer.TokenType) *lexer.Token {
+	for _, child := range p.Children {
+		if t, ok := child.(*lexer.Token); ok && t.Type == tokenType {
+			return t
+		}
+	}
+	return nil
+}
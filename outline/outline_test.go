@@ -0,0 +1,91 @@
+package outline
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+)
+
+const source = `<?php
+namespace App\Models;
+
+define('VERSION', '1.0');
+const LIMIT = 10, /* max */ MAX = 20;
+
+interface Named { public function name(): string; }
+
+abstract class User extends Model implements Named
+{
+    const TABLE = 'users';
+    private static ?int $count = 0, $other;
+    public function __construct(string $name) {}
+    public function name(): string
+    {
+        $format = function ($value) use ($name): string { return $value; };
+        return $format($this->name);
+    }
+}
+
+trait Greets { public $greeting; }
+
+function make(array $attributes = []) {
+    return new class($attributes) extends User {
+        protected function   handle( $a ) {}
+    };
+}
+
+namespace Other;
+
+$double = fn(int $x) => $x * 2;
+`
+
+// dump prints a symbol per line, indented by depth, with its selection
+func dump(b *strings.Builder, symbols []Symbol, depth int) {
+	for _, s := range symbols {
+		fmt.Fprintf(b, "%s%s %s %q [%s]\n", strings.Repeat("  ", depth), s.Kind, s.Name, s.Detail,
+			source[s.SelectionRange.Start:s.SelectionRange.End])
+		dump(b, s.Children, depth+1)
+	}
+}
+
+func TestOutline(t *testing.T) {
+	symbols := Outline(parser.Parse([]byte(source)), []byte(source))
+	var b strings.Builder
+	dump(&b, symbols, 0)
+	expected := `Namespace App\Models "" [App\Models]
+  Constant VERSION "'1.0'" ['VERSION']
+  Constant LIMIT "10" [LIMIT]
+  Constant MAX "20" [MAX]
+  Interface Named "interface Named" [Named]
+    Method name "public function name(): string" [name]
+  Class User "abstract class User extends Model implements Named" [User]
+    Constant TABLE "'users'" [TABLE]
+    Property $count "private static ?int" [$count]
+    Property $other "private static ?int" [$other]
+    Constructor __construct "public function __construct(string $name)" [__construct]
+    Method name "public function name(): string" [name]
+      Closure $format "function ($value) use ($name): string" [$format]
+  Trait Greets "trait Greets" [Greets]
+    Property $greeting "public" [$greeting]
+  Function make "function make(array $attributes = [])" [make]
+    Class class@anonymous "class($attributes) extends User" [class]
+      Method handle "protected function handle( $a )" [handle]
+Namespace Other "" [Other]
+  Closure $double "fn(int $x)" [$double]
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, b.String())
+	}
+
+	first, second := symbols[0], symbols[1]
+	if source[first.Range.Start:first.Range.End] != source[strings.Index(source, "namespace App"):strings.Index(source, "\n\nnamespace Other")] {
+		t.Errorf("expected the first namespace to extend to its last statement, got %q",
+			source[first.Range.Start:first.Range.End])
+	}
+	closure := second.Children[0]
+	if source[closure.Range.Start:closure.Range.End] != "$double = fn(int $x) => $x * 2" {
+		t.Errorf("unexpected closure range %q", source[closure.Range.Start:closure.Range.End])
+	}
+}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"strings"
+
 	"github.com/john-nguyen09/go-phpparser/lexer"
 	"github.com/john-nguyen09/go-phpparser/phrase"
 )
@@ -27,84 +29,140 @@ func (doc *Parser) docCommentStatement() phrase.AstNode {
 	switch t.Type {
 	case lexer.DocumentCommentStart:
 		return doc.next(true)
-	case lexer.DocumentCommentStartline, lexer.Name, lexer.DocumentCommentText:
-		return doc.documentCommentStatementStart()
 	case lexer.DocumentCommentEndline, lexer.Whitespace:
 		return doc.next(true)
 	}
 	if isTagName(t) {
 		return doc.docCommentTag()
 	}
-	return nil
+	return doc.documentCommentStatementStart()
 }
 
+// docCommentDescription groups the description into paragraphs separated by
+// blank lines, each holding text, inline tags and code spans
 func (doc *Parser) docCommentDescription() *phrase.Phrase {
-	doc.start(phrase.DocumentCommentDescription, false)
+	p := doc.start(phrase.DocumentCommentDescription, false)
 	if !isDescriptionStart(doc.peek(0)) {
 		return doc.end()
 	}
-	doc.next(false)
-	t := doc.peek(0)
 	for {
-		if t.Type == lexer.DocumentCommentEndline &&
-			(doc.peek(1).Type != lexer.DocumentCommentStartline || !isDescriptionStart(doc.peek(2))) {
-			doc.next(false)
+		p.Children = append(p.Children, doc.docCommentParagraph())
+		if doc.peek(0).Type != lexer.DocumentCommentEndline {
 			break
 		}
-		if t.Type == lexer.DocumentCommentEnd || t.Type == lexer.EndOfFile {
+		if !doc.isDocCommentParagraphBreak() {
+			doc.next(false)
 			break
 		}
-		doc.next(false)
-		t = doc.peek(0)
+		for doc.peek(0).Type != lexer.DocumentCommentStartline || !isDescriptionStart(doc.peek(1)) {
+			doc.next(false) // blank lines
+		}
+		doc.next(false) // DocumentCommentStartline
 	}
 	return doc.end()
 }
 
-func (doc *Parser) docCommentTypeName() phrase.AstNode {
-	p := doc.start(phrase.TypeDeclaration, false)
-	switch doc.peek(0).Type {
-	case lexer.Callable, lexer.Array:
+func (doc *Parser) docCommentParagraph() *phrase.Phrase {
+	p := doc.start(phrase.DocumentCommentParagraph, false)
+	for {
+		t := doc.peek(0)
+		switch t.Type {
+		case lexer.DocumentCommentEndline:
+			if doc.isDocCommentParagraphBreak() ||
+				doc.peek(1).Type != lexer.DocumentCommentStartline || !isDescriptionStart(doc.peek(2)) {
+				return doc.end()
+			}
+		case lexer.DocumentCommentEnd, lexer.EndOfFile:
+			return doc.end()
+		case lexer.OpenBrace:
+			if isTagName(doc.peek(1)) && doc.docCommentLineHas(2, lexer.CloseBrace) {
+				p.Children = append(p.Children, doc.docCommentInlineTag())
+				continue
+			}
+		case lexer.Backtick:
+			if doc.docCommentLineHas(1, lexer.Backtick) {
+				p.Children = append(p.Children, doc.docCommentCodeSpan())
+				continue
+			}
+		}
+		doc.next(false)
+	}
+}
+
+// docCommentInlineTag parses {@link Foo::bar() description}, {@see Foo}
+// and {@inheritDoc}
+func (doc *Parser) docCommentInlineTag() *phrase.Phrase {
+	p := doc.start(phrase.DocumentCommentInlineTag, false)
+	doc.next(false) // {
+	t := doc.next(false)
+	if (t.Type == lexer.AtLink || t.Type == lexer.AtSee) && doc.peek(0).Type != lexer.CloseBrace {
+		doc.start(phrase.DocumentCommentReference, false)
 		doc.next(false)
-	case lexer.Name, lexer.Backslash:
-		p.Children = append(p.Children, doc.qualifiedName())
-		for doc.peek(0).Type == lexer.Array {
+		for doc.peek(0).Type != lexer.CloseBrace && doc.isDocCommentAdjacent() {
 			doc.next(false)
 		}
-	case lexer.VariableName:
-		doc.next(false)
-	default:
-		doc.end()
-		return nil
+		p.Children = append(p.Children, doc.end())
 	}
+	if doc.peek(0).Type != lexer.CloseBrace {
+		doc.start(phrase.DocumentCommentDescription, false)
+		for doc.peek(0).Type != lexer.CloseBrace {
+			doc.next(false)
+		}
+		p.Children = append(p.Children, doc.end())
+	}
+	doc.next(false) // }
 	return doc.end()
 }
 
-func (doc *Parser) docCommentTypeUnionOrTypeDeclaration() phrase.AstNode {
-	p := doc.start(phrase.TypeUnion, false)
-	typeName := doc.docCommentTypeName()
-	if typeName == nil {
-		doc.end()
-		return nil
+func (doc *Parser) docCommentCodeSpan() *phrase.Phrase {
+	doc.start(phrase.DocumentCommentCodeSpan, false)
+	doc.next(false) // `
+	for doc.peek(0).Type != lexer.Backtick {
+		doc.next(false)
 	}
-	p.Children = append(p.Children, typeName)
-	if doc.peek(0).Type != lexer.Bar {
-		doc.end()
-		return typeName
+	doc.next(false) // `
+	return doc.end()
+}
+
+// docCommentLineHas reports whether a token of tokenType occurs on the current
+// line, looking ahead from the nth token
+func (doc *Parser) docCommentLineHas(n int, tokenType lexer.TokenType) bool {
+	for ; ; n++ {
+		switch t := doc.peek(n); t.Type {
+		case tokenType:
+			return true
+		case lexer.DocumentCommentEndline, lexer.DocumentCommentEnd, lexer.EndOfFile:
+			return false
+		}
 	}
-	for doc.peek(0).Type == lexer.Bar {
-		doc.next(false)
-		typeName = doc.docCommentTypeName()
-		if typeName != nil {
-			p.Children = append(p.Children, typeName)
+}
+
+// isDocCommentParagraphBreak reports whether the line break under the cursor is
+// followed by blank lines and then more description. The lexer folds blank
+// " *" lines into the next DocumentCommentStartline.
+func (doc *Parser) isDocCommentParagraphBreak() bool {
+	n := 1
+	blank := false
+	for {
+		t := doc.peek(n)
+		if t.Type == lexer.DocumentCommentEndline {
+			n++
+		} else if t.Type == lexer.DocumentCommentStartline && doc.peek(n+1).Type == lexer.DocumentCommentEndline {
+			n += 2
 		} else {
 			break
 		}
+		blank = true
 	}
-	return doc.end()
+	t := doc.peek(n)
+	if t.Type != lexer.DocumentCommentStartline || !isDescriptionStart(doc.peek(n+1)) {
+		return false
+	}
+	return blank || strings.ContainsAny(string(doc.lexerState.GetTokenValue(t)), "\r\n")
 }
 
 func (doc *Parser) documentCommentStatementStart() phrase.AstNode {
-	if isTagName(doc.peek(1)) {
+	if doc.peek(0).Type == lexer.DocumentCommentStartline && isTagName(doc.peek(1)) {
 		return doc.docCommentTag()
 	}
 	return doc.docCommentDescription()
@@ -135,6 +193,20 @@ func (doc *Parser) docCommentTag() phrase.AstNode {
 		doc.throwsTag(p)
 	case lexer.AtVar:
 		doc.varTag(p)
+	case lexer.AtTemplate, lexer.AtTemplateCovariant, lexer.AtTemplateContravariant:
+		doc.templateTag(p)
+	case lexer.AtExtends:
+		doc.extendsTag(p)
+	case lexer.AtImplements:
+		doc.implementsTag(p)
+	case lexer.AtUse:
+		doc.useTag(p)
+	case lexer.AtMixin:
+		doc.mixinTag(p)
+	case lexer.AtParamOut:
+		doc.paramOutTag(p)
+	case lexer.AtAssert, lexer.AtAssertIfTrue, lexer.AtAssertIfFalse:
+		doc.assertTag(p)
 	default:
 		p.Children = append(p.Children, doc.docCommentDescription())
 	}
@@ -147,7 +219,7 @@ func (doc *Parser) authorTag(p *phrase.Phrase) {
 	doc.start(phrase.DocumentCommentAuthor, false)
 	doc.next(false) // Tag name
 	t := doc.peek(0)
-	for t.Type != lexer.DocumentCommentEndline && t.Type != lexer.LessThan {
+	for !isDocCommentLineEnd(t) && t.Type != lexer.LessThan {
 		doc.next(false)
 		t = doc.peek(0)
 	}
@@ -157,15 +229,24 @@ func (doc *Parser) authorTag(p *phrase.Phrase) {
 		doc.start(phrase.DocumentCommentEmail, false)
 		doc.next(false)
 		t := doc.peek(0)
-		for t.Type != lexer.DocumentCommentEndline && t.Type != lexer.GreaterThan {
+		for !isDocCommentLineEnd(t) && t.Type != lexer.GreaterThan {
 			doc.next(false)
 			t = doc.peek(0)
 		}
-		doc.next(false)
+		if t.Type == lexer.GreaterThan || t.Type == lexer.DocumentCommentEndline {
+			doc.next(false)
+		}
 		p.Children = append(p.Children, doc.end())
 	}
 }
 
+// isDocCommentLineEnd reports whether t ends the line of a tag, which is also
+// the case at the end of the comment or of an unterminated comment
+func isDocCommentLineEnd(t *lexer.Token) bool {
+	return t.Type == lexer.DocumentCommentEndline || t.Type == lexer.DocumentCommentEnd ||
+		t.Type == lexer.EndOfFile
+}
+
 func (doc *Parser) deprecatedTag(p *phrase.Phrase) {
 	p.Type = phrase.DocumentCommentDeprecatedTag
 
@@ -181,7 +262,7 @@ func (doc *Parser) deprecatedTag(p *phrase.Phrase) {
 func (doc *Parser) globalTag(p *phrase.Phrase) {
 	p.Type = phrase.DocumentCommentGlobalTag
 
-	typeListOrName := doc.docCommentTypeUnionOrTypeDeclaration()
+	typeListOrName := doc.docCommentType(false)
 	if typeListOrName != nil {
 		p.Children = append(p.Children, typeListOrName)
 	} else {
@@ -197,7 +278,7 @@ func (doc *Parser) globalTag(p *phrase.Phrase) {
 
 func (doc *Parser) docCommentParameterDeclaration() phrase.AstNode {
 	p := doc.start(phrase.ParameterDeclaration, false)
-	typeListOrName := doc.docCommentTypeUnionOrTypeDeclaration()
+	typeListOrName := doc.docCommentType(false)
 	if typeListOrName != nil {
 		p.Children = append(p.Children, typeListOrName)
 	}
@@ -214,7 +295,8 @@ func (doc *Parser) docCommentParameterDeclaration() phrase.AstNode {
 func (doc *Parser) docCommentParameterValue() *phrase.Phrase {
 	doc.start(phrase.ParameterValue, false)
 	t := doc.peek(0)
-	for t.Type != lexer.CloseParenthesis && t.Type != lexer.Comma {
+	for t.Type != lexer.CloseParenthesis && t.Type != lexer.Comma &&
+		t.Type != lexer.DocumentCommentEnd && t.Type != lexer.EndOfFile {
 		doc.next(false)
 		t = doc.peek(0)
 	}
@@ -226,7 +308,7 @@ func (doc *Parser) methodTag(p *phrase.Phrase) {
 
 	doc.optional(lexer.Static)
 	if doc.peek(1).Type != lexer.OpenParenthesis {
-		typeListOrName := doc.docCommentTypeUnionOrTypeDeclaration()
+		typeListOrName := doc.docCommentType(false)
 		if typeListOrName != nil {
 			p.Children = append(p.Children, typeListOrName)
 		}
@@ -251,12 +333,14 @@ func (doc *Parser) methodTag(p *phrase.Phrase) {
 func (doc *Parser) paramTag(p *phrase.Phrase) {
 	p.Type = phrase.DocumentCommentParamTag
 
-	typeListOrName := doc.docCommentTypeUnionOrTypeDeclaration()
+	typeListOrName := doc.docCommentType(false)
 	if typeListOrName != nil {
 		p.Children = append(p.Children, typeListOrName)
 	} else {
 		doc.error(lexer.Name)
 	}
+	doc.optional(lexer.Ampersand)
+	doc.optional(lexer.Ellipsis)
 	doc.expect(lexer.VariableName)
 	desc := doc.docCommentDescription()
 	if len(desc.Children) > 0 {
@@ -268,7 +352,7 @@ func (doc *Parser) propertyTag(p *phrase.Phrase) {
 	p.Type = phrase.DocumentCommentPropertyTag
 
 	if doc.peek(0).Type != lexer.VariableName {
-		typeListOrName := doc.docCommentTypeUnionOrTypeDeclaration()
+		typeListOrName := doc.docCommentType(false)
 		if typeListOrName != nil {
 			p.Children = append(p.Children, typeListOrName)
 		} else {
@@ -285,7 +369,7 @@ func (doc *Parser) propertyTag(p *phrase.Phrase) {
 func (doc *Parser) returnTag(p *phrase.Phrase) {
 	p.Type = phrase.DocumentCommentReturnTag
 
-	typeListOrName := doc.docCommentTypeUnionOrTypeDeclaration()
+	typeListOrName := doc.docCommentType(false)
 	if typeListOrName != nil {
 		p.Children = append(p.Children, typeListOrName)
 	} else {
@@ -300,7 +384,7 @@ func (doc *Parser) returnTag(p *phrase.Phrase) {
 func (doc *Parser) throwsTag(p *phrase.Phrase) {
 	p.Type = phrase.DocumentCommentThrowsTag
 
-	typeListOrName := doc.docCommentTypeUnionOrTypeDeclaration()
+	typeListOrName := doc.docCommentType(false)
 	if typeListOrName != nil {
 		p.Children = append(p.Children, typeListOrName)
 	} else {
@@ -315,7 +399,7 @@ func (doc *Parser) throwsTag(p *phrase.Phrase) {
 func (doc *Parser) varTag(p *phrase.Phrase) {
 	p.Type = phrase.DocumentCommentVarTag
 
-	typeListOrName := doc.docCommentTypeUnionOrTypeDeclaration()
+	typeListOrName := doc.docCommentType(false)
 	if typeListOrName != nil {
 		p.Children = append(p.Children, typeListOrName)
 	} else {
@@ -328,16 +412,96 @@ func (doc *Parser) varTag(p *phrase.Phrase) {
 	}
 }
 
+func (doc *Parser) templateTag(p *phrase.Phrase) {
+	p.Type = phrase.DocumentCommentTemplateTag
+
+	doc.expect(lexer.Name)
+	t := doc.peek(0)
+	// @template T of Foo, or Psalm's @template T as Foo
+	if (doc.isDocCommentKeyword(t, "of") || doc.isDocCommentKeyword(t, "as") ||
+		doc.isDocCommentKeyword(t, "super")) && isDocCommentTypeStart(doc.peek(1)) {
+		doc.next(false)
+		p.Children = append(p.Children, doc.docCommentRequiredType(false))
+	}
+	if doc.peek(0).Type == lexer.Equals {
+		doc.next(false)
+		p.Children = append(p.Children, doc.docCommentRequiredType(false))
+	}
+	desc := doc.docCommentDescription()
+	if len(desc.Children) > 0 {
+		p.Children = append(p.Children, desc)
+	}
+}
+
+func (doc *Parser) extendsTag(p *phrase.Phrase) {
+	p.Type = phrase.DocumentCommentExtendsTag
+	doc.docCommentTypeAndDescription(p)
+}
+
+func (doc *Parser) implementsTag(p *phrase.Phrase) {
+	p.Type = phrase.DocumentCommentImplementsTag
+	doc.docCommentTypeAndDescription(p)
+}
+
+func (doc *Parser) useTag(p *phrase.Phrase) {
+	p.Type = phrase.DocumentCommentUseTag
+	doc.docCommentTypeAndDescription(p)
+}
+
+func (doc *Parser) mixinTag(p *phrase.Phrase) {
+	p.Type = phrase.DocumentCommentMixinTag
+	doc.docCommentTypeAndDescription(p)
+}
+
+func (doc *Parser) paramOutTag(p *phrase.Phrase) {
+	doc.paramTag(p)
+	p.Type = phrase.DocumentCommentParamOutTag
+}
+
+func (doc *Parser) assertTag(p *phrase.Phrase) {
+	p.Type = phrase.DocumentCommentAssertTag
+
+	// !null negates the assertion and =Foo asserts equality rather than type
+	if doc.optional(lexer.Exclamation) == nil {
+		doc.optional(lexer.Equals)
+	}
+	typeListOrName := doc.docCommentType(false)
+	if typeListOrName != nil {
+		p.Children = append(p.Children, typeListOrName)
+	} else {
+		doc.error(lexer.Name)
+	}
+	// $x, $this->prop or $this->method()
+	doc.expect(lexer.VariableName)
+	for doc.peek(0).Type == lexer.Arrow {
+		doc.next(false)
+		doc.expect(lexer.Name)
+		if doc.peek(0).Type == lexer.OpenParenthesis && doc.isDocCommentAdjacent() {
+			doc.next(false)
+			doc.expect(lexer.CloseParenthesis)
+		}
+	}
+	desc := doc.docCommentDescription()
+	if len(desc.Children) > 0 {
+		p.Children = append(p.Children, desc)
+	}
+}
+
+func (doc *Parser) docCommentTypeAndDescription(p *phrase.Phrase) {
+	typeListOrName := doc.docCommentType(false)
+	if typeListOrName != nil {
+		p.Children = append(p.Children, typeListOrName)
+	} else {
+		doc.error(lexer.Name)
+	}
+	desc := doc.docCommentDescription()
+	if len(desc.Children) > 0 {
+		p.Children = append(p.Children, desc)
+	}
+}
+
 func isDocumentCommentStatementStart(t *lexer.Token) bool {
-	switch t.Type {
-	case lexer.DocumentCommentStart,
-		lexer.DocumentCommentStartline,
-		lexer.DocumentCommentEndline,
-		lexer.Name,
-		lexer.DocumentCommentText:
-		return true
-	}
-	return isTagName(t)
+	return t.Type != lexer.DocumentCommentEnd && t.Type != lexer.EndOfFile
 }
 
 func isTagName(t *lexer.Token) bool {
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+// DocComments maps a declaration or statement to the doc comment directly
+// preceding it
+type DocComments map[*phrase.Phrase]*phrase.Phrase
+
+// AttachDocComments pairs doc comments with the ClassDeclaration,
+// InterfaceDeclaration, TraitDeclaration, MethodDeclaration,
+// PropertyDeclaration, FunctionDeclaration, ConstDeclaration or
+// ClassConstDeclaration that follows them in the same list, and @var
+// comments with the statement that follows them. As in PHP, comments and
+// whitespace in between do not break the association. Other doc comments
+// document nothing.
+func AttachDocComments(root *phrase.Phrase) DocComments {
+	docs := DocComments{}
+	docs.attach(root)
+	return docs
+}
+
+func (docs DocComments) attach(p *phrase.Phrase) {
+	var pending *phrase.Phrase
+	for _, child := range p.Children {
+		switch child := child.(type) {
+		case *lexer.Token:
+			if child.Type != lexer.Whitespace && child.Type != lexer.Comment {
+				pending = nil
+			}
+		case *phrase.ParseError:
+			pending = nil
+			docs.attach(&child.Phrase)
+		case *phrase.Phrase:
+			if child.Type == phrase.DocumentComment {
+				pending = child
+				continue
+			}
+			if pending != nil && documents(pending, p, child) {
+				docs[child] = pending
+			}
+			pending = nil
+			docs.attach(child)
+		}
+	}
+}
+
+// documents reports whether doc documents p, a child of parent
+func documents(doc *phrase.Phrase, parent *phrase.Phrase, p *phrase.Phrase) bool {
+	switch p.Type {
+	case phrase.ClassDeclaration,
+		phrase.InterfaceDeclaration,
+		phrase.TraitDeclaration,
+		phrase.MethodDeclaration,
+		phrase.PropertyDeclaration,
+		phrase.FunctionDeclaration,
+		phrase.ConstDeclaration,
+		phrase.ClassConstDeclaration:
+		return true
+	}
+	return parent.Type == phrase.StatementList && hasToken(doc, lexer.AtVar)
+}
+
+func hasToken(node phrase.AstNode, tokenType lexer.TokenType) bool {
+	switch node := node.(type) {
+	case *lexer.Token:
+		return node.Type == tokenType
+	case *phrase.Phrase:
+		for _, child := range node.Children {
+			if hasToken(child, tokenType) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,359 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+// docCommentType parses a PHPDoc type, e.g. array<int, User>|null. Conditional
+// types are only recognised with a $parameter subject unless inParentheses is
+// set, otherwise "@return bool is ..." would swallow the description.
+func (doc *Parser) docCommentType(inParentheses bool) phrase.AstNode {
+	subject := doc.docCommentTypeUnion()
+	if subject == nil {
+		return nil
+	}
+	if !doc.isDocCommentKeyword(doc.peek(0), "is") ||
+		(!inParentheses && !isDocCommentVariableType(subject)) {
+		return subject
+	}
+
+	p := doc.start(phrase.TypeConditional, true)
+	p.Children = append(p.Children, subject)
+	doc.next(false) // is
+	if doc.isDocCommentKeyword(doc.peek(0), "not") {
+		doc.next(false)
+	}
+	p.Children = append(p.Children, doc.docCommentRequiredType(false))
+	doc.docCommentLineBreaks()
+	doc.expect(lexer.Question)
+	doc.docCommentLineBreaks()
+	p.Children = append(p.Children, doc.docCommentRequiredType(inParentheses))
+	doc.docCommentLineBreaks()
+	doc.expect(lexer.Colon)
+	doc.docCommentLineBreaks()
+	p.Children = append(p.Children, doc.docCommentRequiredType(inParentheses))
+
+	return doc.end()
+}
+
+func (doc *Parser) docCommentRequiredType(inParentheses bool) phrase.AstNode {
+	typeNode := doc.docCommentType(inParentheses)
+	if typeNode == nil {
+		doc.start(phrase.TypeDeclaration, false)
+		doc.error(lexer.Name)
+		return doc.end()
+	}
+	return typeNode
+}
+
+func (doc *Parser) docCommentTypeUnion() phrase.AstNode {
+	typeNode := doc.docCommentTypeIntersection()
+	if typeNode == nil || doc.peek(0).Type != lexer.Bar {
+		return typeNode
+	}
+
+	p := doc.start(phrase.TypeUnion, true)
+	p.Children = append(p.Children, typeNode)
+	for doc.peek(0).Type == lexer.Bar {
+		doc.next(false)
+		typeNode = doc.docCommentTypeIntersection()
+		if typeNode == nil {
+			doc.error(lexer.Name)
+			break
+		}
+		p.Children = append(p.Children, typeNode)
+	}
+
+	return doc.end()
+}
+
+func (doc *Parser) docCommentTypeIntersection() phrase.AstNode {
+	typeNode := doc.docCommentTypeName()
+	if typeNode == nil || !doc.isDocCommentIntersection() {
+		return typeNode
+	}
+
+	p := doc.start(phrase.TypeIntersection, true)
+	p.Children = append(p.Children, typeNode)
+	for doc.isDocCommentIntersection() {
+		doc.next(false) // &
+		p.Children = append(p.Children, doc.docCommentTypeName())
+	}
+
+	return doc.end()
+}
+
+// isDocCommentIntersection tells A&B apart from a by-reference parameter
+// such as "@param int &$x"
+func (doc *Parser) isDocCommentIntersection() bool {
+	if doc.peek(0).Type != lexer.Ampersand {
+		return false
+	}
+	t := doc.peek(1)
+	return t.Type != lexer.VariableName && isDocCommentTypeStart(t)
+}
+
+func (doc *Parser) docCommentTypeName() phrase.AstNode {
+	if !isDocCommentTypeStart(doc.peek(0)) {
+		return nil
+	}
+	p := doc.start(phrase.TypeDeclaration, false)
+	doc.optional(lexer.Question)
+
+	switch doc.peek(0).Type {
+	case lexer.Name, lexer.Backslash:
+		name := doc.qualifiedName()
+		p.Children = append(p.Children, name)
+		doc.docCommentTypeNameSuffix(p, name)
+	case lexer.OpenParenthesis:
+		doc.next(false)
+		doc.docCommentLineBreaks()
+		p.Children = append(p.Children, doc.docCommentRequiredType(true))
+		doc.docCommentLineBreaks()
+		doc.expect(lexer.CloseParenthesis)
+	case lexer.Static,
+		lexer.VariableName,
+		lexer.StringLiteral,
+		lexer.IntegerLiteral,
+		lexer.DocumentCommentVersion:
+		doc.next(false)
+	default:
+		doc.error(lexer.Name)
+		return doc.end()
+	}
+
+	for {
+		switch doc.peek(0).Type {
+		case lexer.Array:
+			doc.next(false) // []
+			continue
+		case lexer.OpenBracket:
+			if !doc.isDocCommentAdjacent() {
+				break
+			}
+			// offset access, e.g. T['key']
+			doc.next(false)
+			p.Children = append(p.Children, doc.docCommentRequiredType(false))
+			doc.expect(lexer.CloseBracket)
+			continue
+		}
+		break
+	}
+
+	return doc.end()
+}
+
+// docCommentTypeNameSuffix parses what may directly follow a type name: generic
+// arguments, an array shape, a callable signature or a constant reference
+func (doc *Parser) docCommentTypeNameSuffix(p *phrase.Phrase, name phrase.AstNode) {
+	if !doc.isDocCommentAdjacent() {
+		return
+	}
+
+	switch doc.peek(0).Type {
+	case lexer.LessThan:
+		p.Children = append(p.Children, doc.docCommentTypeArgumentList())
+	case lexer.OpenBrace:
+		if isTagName(doc.peek(1)) {
+			// an inline tag such as {@link Foo}
+			return
+		}
+		p.Children = append(p.Children, doc.docCommentArrayShape())
+	case lexer.OpenParenthesis:
+		if !doc.isDocCommentCallableName(name) {
+			return
+		}
+		doc.next(false)
+		doc.docCommentLineBreaks()
+		if doc.peek(0).Type != lexer.CloseParenthesis {
+			list := doc.start(phrase.ParameterDeclarationList, false)
+			doc.docCommentTypeElements(list, lexer.CloseParenthesis, doc.docCommentCallableParameter)
+			p.Children = append(p.Children, doc.end())
+		}
+		doc.expect(lexer.CloseParenthesis)
+		if doc.peek(0).Type == lexer.Colon {
+			returnType := doc.start(phrase.ReturnType, false)
+			doc.next(false) // :
+			typeName := doc.docCommentTypeName()
+			if typeName == nil {
+				doc.error(lexer.Name)
+			} else {
+				returnType.Children = append(returnType.Children, typeName)
+			}
+			p.Children = append(p.Children, doc.end())
+		}
+	case lexer.ColonColon:
+		doc.next(false)
+		// Foo::BAR, Foo::BAR_* or Foo::*
+		if doc.optional(lexer.Name) == nil {
+			doc.expect(lexer.Asterisk)
+		} else if doc.peek(0).Type == lexer.Asterisk && doc.isDocCommentAdjacent() {
+			doc.next(false)
+		}
+	}
+}
+
+func (doc *Parser) docCommentTypeArgumentList() *phrase.Phrase {
+	p := doc.start(phrase.TypeArgumentList, false)
+	doc.next(false) // <
+	doc.docCommentTypeElements(p, lexer.GreaterThan, doc.docCommentTypeArgument)
+	doc.expect(lexer.GreaterThan)
+
+	return doc.end()
+}
+
+// docCommentTypeArgument returns the type itself unless it carries a
+// variance keyword or is a * wildcard
+func (doc *Parser) docCommentTypeArgument() phrase.AstNode {
+	t := doc.peek(0)
+	if t.Type == lexer.Asterisk {
+		doc.start(phrase.TypeArgument, false)
+		doc.next(false)
+		return doc.end()
+	}
+	if (doc.isDocCommentKeyword(t, "covariant") || doc.isDocCommentKeyword(t, "contravariant")) &&
+		isDocCommentTypeStart(doc.peek(1)) {
+		p := doc.start(phrase.TypeArgument, false)
+		doc.next(false)
+		p.Children = append(p.Children, doc.docCommentRequiredType(false))
+		return doc.end()
+	}
+
+	return doc.docCommentRequiredType(false)
+}
+
+func (doc *Parser) docCommentArrayShape() *phrase.Phrase {
+	p := doc.start(phrase.TypeArrayShape, false)
+	doc.next(false) // {
+	doc.docCommentTypeElements(p, lexer.CloseBrace, doc.docCommentArrayShapeItem)
+	doc.expect(lexer.CloseBrace)
+
+	return doc.end()
+}
+
+func (doc *Parser) docCommentArrayShapeItem() phrase.AstNode {
+	p := doc.start(phrase.TypeArrayShapeItem, false)
+	t := doc.peek(0)
+
+	if t.Type == lexer.Ellipsis {
+		// unsealed shape, optionally ...<K, V>
+		doc.next(false)
+		if doc.peek(0).Type == lexer.LessThan {
+			p.Children = append(p.Children, doc.docCommentTypeArgumentList())
+		}
+		return doc.end()
+	}
+
+	switch t.Type {
+	case lexer.Name, lexer.StringLiteral, lexer.IntegerLiteral:
+		t1 := doc.peek(1)
+		if t1.Type == lexer.Colon ||
+			(t1.Type == lexer.Question && doc.peek(2).Type == lexer.Colon) {
+			doc.next(false) // key
+			doc.optional(lexer.Question)
+			doc.next(false) // :
+		}
+	}
+	p.Children = append(p.Children, doc.docCommentRequiredType(false))
+
+	return doc.end()
+}
+
+func (doc *Parser) docCommentCallableParameter() phrase.AstNode {
+	p := doc.start(phrase.ParameterDeclaration, false)
+	typeNode := doc.docCommentType(false)
+	if typeNode == nil {
+		doc.error(lexer.Name)
+	} else {
+		p.Children = append(p.Children, typeNode)
+	}
+	doc.optional(lexer.Ampersand)
+	doc.optional(lexer.Ellipsis)
+	doc.optional(lexer.VariableName)
+	doc.optional(lexer.Equals)
+
+	return doc.end()
+}
+
+// docCommentTypeElements parses comma separated elements until closeType,
+// allowing a trailing comma and line breaks in between
+func (doc *Parser) docCommentTypeElements(
+	p *phrase.Phrase, closeType lexer.TokenType, elementFunction func() phrase.AstNode) {
+	for {
+		doc.docCommentLineBreaks()
+		t := doc.peek(0)
+		if t.Type == closeType || t.Type == lexer.DocumentCommentEnd || t.Type == lexer.EndOfFile {
+			return
+		}
+		p.Children = append(p.Children, elementFunction())
+		doc.docCommentLineBreaks()
+		if doc.optional(lexer.Comma) == nil {
+			return
+		}
+	}
+}
+
+// docCommentLineBreaks consumes line breaks inside a bracketed type
+func (doc *Parser) docCommentLineBreaks() {
+	for {
+		switch doc.peek(0).Type {
+		case lexer.DocumentCommentEndline, lexer.DocumentCommentStartline:
+			doc.next(false)
+			continue
+		}
+		return
+	}
+}
+
+// isDocCommentAdjacent reports whether the next token directly follows the
+// previous one, so that "Foo<T>" is generic but "Foo <b>bold</b>" is not
+func (doc *Parser) isDocCommentAdjacent() bool {
+	doc.peek(0)
+	t := doc.tokenBuffer.Peek()
+	return t.Type != lexer.Comment && t.Type != lexer.Whitespace
+}
+
+func (doc *Parser) isDocCommentKeyword(t *lexer.Token, keyword string) bool {
+	return t.Type == lexer.Name && t.Length == len(keyword) &&
+		strings.EqualFold(string(doc.lexerState.GetTokenValue(t)), keyword)
+}
+
+func (doc *Parser) isDocCommentCallableName(name phrase.AstNode) bool {
+	t := phrase.LastToken(name)
+	if t == nil || t.Type != lexer.Name {
+		return false
+	}
+	switch strings.ToLower(string(doc.lexerState.GetTokenValue(t))) {
+	case "callable", "closure", "pure-callable", "pure-closure":
+		return true
+	}
+	return false
+}
+
+func isDocCommentTypeStart(t *lexer.Token) bool {
+	switch t.Type {
+	case lexer.Name,
+		lexer.Backslash,
+		lexer.Question,
+		lexer.OpenParenthesis,
+		lexer.Static,
+		lexer.VariableName,
+		lexer.StringLiteral,
+		lexer.IntegerLiteral,
+		lexer.DocumentCommentVersion:
+		return true
+	}
+	return false
+}
+
+func isDocCommentVariableType(node phrase.AstNode) bool {
+	p, ok := node.(*phrase.Phrase)
+	if !ok || p.Type != phrase.TypeDeclaration || len(p.Children) == 0 {
+		return false
+	}
+	t, ok := p.Children[0].(*lexer.Token)
+	return ok && t.Type == lexer.VariableName
+}
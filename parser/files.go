@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"context"
+	"io/ioutil"
+	"runtime"
+	"sync"
+
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+// FileResult is the result of parsing a file with ParseFiles
+type FileResult struct {
+	Path   string
+	Source []byte
+	Root   *phrase.Phrase
+	// Err is the error reading the file or the error of the context when
+	// ParseFiles was cancelled before parsing it
+	Err error
+}
+
+// ParseFiles parses files concurrently with opts.Workers workers, each with
+// its own Pools. There is one result per path, sent in the order the files
+// are done, and the channel is closed after the last one. Once ctx is done
+// the remaining files are reported with its error instead of being parsed.
+// The channel must be drained.
+func ParseFiles(ctx context.Context, paths []string, opts Options) <-chan FileResult {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	jobs := make(chan string)
+	results := make(chan FileResult, workers)
+	go func() {
+		for _, path := range paths {
+			jobs <- path
+		}
+		close(jobs)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			pools := NewPools()
+			for path := range jobs {
+				results <- parseFile(ctx, path, opts, pools)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func parseFile(ctx context.Context, path string, opts Options, pools *Pools) FileResult {
+	result := FileResult{Path: path}
+	if result.Err = ctx.Err(); result.Err != nil {
+		return result
+	}
+	result.Source, result.Err = ioutil.ReadFile(path)
+	if result.Err == nil {
+		result.Root = ParseWithPools(result.Source, opts, pools)
+	}
+	return result
+}
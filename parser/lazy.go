@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+// lazyBody records a body from its open brace to the matching close brace as
+// a LazyBody phrase of its tokens. Braces in strings, heredocs and comments
+// are not brace tokens since the lexer scans those in their own modes, but
+// the CurlyOpen of "{$a}" and the DollarCurlyOpen of "${a}" are closed by a
+// CloseBrace. Without a matching close brace the body runs to the end of the
+// source.
+func (doc *Parser) lazyBody() *phrase.Phrase {
+	doc.start(phrase.LazyBody, false)
+	doc.errorPhrase = nil
+
+	depth := 0
+	for {
+		t := doc.peek(0)
+		if t.Type == lexer.EndOfFile {
+			// take the trailing whitespace and comments, which Parse skips
+			// inside the body
+			doc.next(false)
+			// Parse reports the missing close brace inside the body and
+			// nothing after it, so keep recovering until ParseBody reports it
+			doc.errorPhrase = &phrase.ParseError{}
+			break
+		}
+		doc.next(false)
+		switch t.Type {
+		case lexer.OpenBrace, lexer.CurlyOpen, lexer.DollarCurlyOpen:
+			depth++
+		case lexer.CloseBrace:
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+
+	return doc.end()
+}
+
+// IsLazy reports whether body, a FunctionDeclarationBody or a
+// MethodDeclarationBody, was recorded by a lazy parse and is not parsed yet
+func IsLazy(body *phrase.Phrase) bool {
+	return lazyIndex(body) >= 0
+}
+
+func lazyIndex(body *phrase.Phrase) int {
+	for i, child := range body.Children {
+		if p, ok := child.(*phrase.Phrase); ok && p.Type == phrase.LazyBody {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseBody parses a body recorded by a lazy parse of source in place, giving
+// it the children Parse would have. The tokens of the body are reused, so the
+// source is not lexed again. Bodies which are not lazy are left as they are.
+// With opts.LazyBodies the bodies nested in body are recorded lazily in turn.
+func ParseBody(source []byte, body *phrase.Phrase, opts Options) {
+	i := lazyIndex(body)
+	if i < 0 {
+		return
+	}
+	lazy := body.Children[i].(*phrase.Phrase)
+	tokens := &tokenSlice{source: source, tokens: make([]*lexer.Token, 0, len(lazy.Children))}
+	for _, child := range lazy.Children {
+		tokens.tokens = append(tokens.tokens, child.(*lexer.Token))
+	}
+	last := tokens.tokens[len(tokens.tokens)-1]
+	tokens.eof = &lexer.Token{Type: lexer.EndOfFile, Offset: last.Offset + last.Length}
+
+	doc := newParser(tokens, opts, phrase.NewPool(phrase.DefaultBlockSize))
+	doc.start(phrase.Unknown, true)
+	cs := doc.compoundStatement()
+	// tokens left after an unbalanced body still belong to it
+	for doc.tokenBuffer.Length() > 0 {
+		if t := doc.tokenBuffer.Remove(); t.Type != lexer.EndOfFile {
+			cs.Children = append(cs.Children, t)
+		}
+	}
+	for _, t := range tokens.tokens {
+		cs.Children = append(cs.Children, t)
+	}
+
+	if body.Type == phrase.FunctionDeclarationBody {
+		// function bodies are compound statements themselves
+		body.Children = cs.Children
+	} else {
+		body.Children[i] = cs
+	}
+}
+
+// tokenSlice is a token source over the tokens of a lazy body
+type tokenSlice struct {
+	source []byte
+	tokens []*lexer.Token
+	eof    *lexer.Token
+}
+
+func (s *tokenSlice) Lex() *lexer.Token {
+	if len(s.tokens) == 0 {
+		return s.eof
+	}
+	t := s.tokens[0]
+	s.tokens = s.tokens[1:]
+	return t
+}
+
+func (s *tokenSlice) GetTokenValue(t *lexer.Token) []rune {
+	return []rune(string(s.source[t.Offset : t.Offset+t.Length]))
+}
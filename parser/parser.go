@@ -2,6 +2,7 @@ package parser
 
 import (
 	"errors"
+	"io"
 	"reflect"
 
 	"github.com/john-nguyen09/go-phpparser/lexer"
@@ -149,6 +150,8 @@ func precedenceAssociativityTuple(t *lexer.Token) (int, Associativity) {
 		return 32, Right
 	case lexer.DotEquals:
 		return 32, Right
+	case lexer.QuestionQuestionEquals:
+		return 32, Right
 	case lexer.PlusEquals:
 		return 32, Right
 	case lexer.MinusEquals:
@@ -219,6 +222,7 @@ func binaryOpToPhraseType(t *lexer.Token) phrase.PhraseType {
 		lexer.AsteriskAsteriskEquals,
 		lexer.ForwardslashEquals,
 		lexer.DotEquals,
+		lexer.QuestionQuestionEquals,
 		lexer.PercentEquals,
 		lexer.AmpersandEquals,
 		lexer.BarEquals,
@@ -233,13 +237,37 @@ func binaryOpToPhraseType(t *lexer.Token) phrase.PhraseType {
 	}
 }
 
+// tokenSource is where the parser reads tokens from, a lexer or the tokens
+// of a lazily parsed body
+type tokenSource interface {
+	Lex() *lexer.Token
+	GetTokenValue(t *lexer.Token) []rune
+}
+
 type Parser struct {
-	lexerState      *lexer.Lexer
+	lexerState      tokenSource
 	tokenBuffer     *TokenQueue
 	phraseStack     []*phrase.Phrase
 	errorPhrase     *phrase.ParseError
 	recoverSetStack [][]lexer.TokenType
 	pool            *phrase.Pool
+	opts            Options
+	// stream is set when reading from an io.Reader and lastOffset is the
+	// offset of the last token next returned, whose text may still be needed
+	stream     *lexer.Lexer
+	lastOffset int
+}
+
+// Options changes how a source is parsed
+type Options struct {
+	// LazyBodies records the bodies of functions, methods and closures as
+	// LazyBody phrases of their tokens, see ParseBody
+	LazyBodies bool
+	// Workers is the number of files ParseFiles parses at once,
+	// runtime.NumCPU() when not positive
+	Workers int
+	// Lexer are the options of the lexer, such as which open tags it knows
+	Lexer lexer.Options
 }
 
 func tokenTypeIndexOf(haystack []lexer.TokenType, needle lexer.TokenType) int {
@@ -252,20 +280,92 @@ func tokenTypeIndexOf(haystack []lexer.TokenType, needle lexer.TokenType) int {
 	return -1
 }
 
+// TreeVersion identifies the trees built by Parse. It is increased whenever
+// Parse returns a different tree for some source, so that trees cached by
+// earlier versions are not reused.
+const TreeVersion = 6
+
 func Parse(source []byte) *phrase.Phrase {
-	doc := &Parser{
-		lexer.NewLexer(source, nil, 0),
-		NewTokenQueue(),
-		make([]*phrase.Phrase, 0),
-		nil,
-		make([][]lexer.TokenType, 0),
+	return ParseWithOptions(source, Options{})
+}
+
+func ParseWithOptions(source []byte, opts Options) *phrase.Phrase {
+	return ParseWithPools(source, opts, NewPools())
+}
+
+// Pools are the token and phrase pools of parses
+type Pools struct {
+	Tokens  *lexer.Pool
+	Phrases *phrase.Pool
+}
+
+func NewPools() *Pools {
+	return &Pools{
+		lexer.NewPool(lexer.DefaultBlockSize),
 		phrase.NewPool(phrase.DefaultBlockSize),
 	}
+}
+
+// ParseWithPools parses source allocating from pools, which saves allocating
+// new blocks for each source. Parses using the same pools must not run
+// concurrently.
+func ParseWithPools(source []byte, opts Options, pools *Pools) *phrase.Phrase {
+	stmtList, _ := parseWithPools(source, opts, pools)
+	return stmtList
+}
+
+// ParseWithDiagnostics parses source like ParseWithOptions and also returns
+// the lexical errors of the source, such as unterminated comments and
+// strings, which are not parse errors of the tree
+func ParseWithDiagnostics(source []byte, opts Options) (*phrase.Phrase, []lexer.Diagnostic) {
+	return parseWithPools(source, opts, NewPools())
+}
+
+func parseWithPools(source []byte, opts Options, pools *Pools) (*phrase.Phrase, []lexer.Diagnostic) {
+	tokens := lexer.NewLexerWithPool(source, nil, 0, pools.Tokens)
+	tokens.SetOptions(opts.Lexer)
+	doc := newParser(tokens, opts, pools.Phrases)
 	stmtList := doc.statementList([]lexer.TokenType{lexer.EndOfFile})
 	//append trailing hidden tokens
 	doc.hidden(stmtList)
 
-	return stmtList
+	return stmtList, tokens.Diagnostics()
+}
+
+func newParser(tokens tokenSource, opts Options, pool *phrase.Pool) *Parser {
+	return &Parser{
+		lexerState:      tokens,
+		tokenBuffer:     NewTokenQueue(),
+		phraseStack:     make([]*phrase.Phrase, 0),
+		recoverSetStack: make([][]lexer.TokenType, 0),
+		pool:            pool,
+		opts:            opts,
+	}
+}
+
+// ParseReader parses a source read from r with a lexer buffering bufferSize
+// bytes at a time, see lexer.NewReaderLexer. The error is the error reading
+// r, the tree then covers the source read before it.
+func ParseReader(r io.Reader, opts Options, bufferSize int) (*phrase.Phrase, error) {
+	pools := NewPools()
+	stream := lexer.NewReaderLexerWithPool(r, nil, bufferSize, pools.Tokens)
+	stream.SetOptions(opts.Lexer)
+	doc := newParser(stream, opts, pools.Phrases)
+	doc.stream = stream
+	stmtList := doc.statementList([]lexer.TokenType{lexer.EndOfFile})
+	doc.hidden(stmtList)
+
+	return stmtList, stream.Err()
+}
+
+// lex returns the next token of the source. A streaming lexer keeps the
+// bytes of the last token returned by next and the tokens after it, which
+// the parser may still look at.
+func (doc *Parser) lex() *lexer.Token {
+	if doc.stream != nil {
+		doc.stream.Retain(doc.lastOffset)
+	}
+	return doc.lexerState.Lex()
 }
 
 func (doc *Parser) popRecover() []lexer.TokenType {
@@ -317,11 +417,11 @@ func (doc *Parser) hidden(p *phrase.Phrase) {
 			t = doc.tokenBuffer.Peek()
 			shouldRemove = true
 		} else {
-			t = doc.lexerState.Lex()
+			t = doc.lex()
 			shouldAdd = true
 		}
 
-		if t.Type < lexer.Comment {
+		if t.Type != lexer.Comment && t.Type != lexer.Whitespace {
 			if shouldAdd {
 				doc.tokenBuffer.Add(t)
 			}
@@ -376,7 +476,7 @@ func (doc *Parser) next(doNotPush bool) *lexer.Token {
 	if doc.tokenBuffer.Length() > 0 {
 		t = doc.tokenBuffer.Remove()
 	} else {
-		t = doc.lexerState.Lex()
+		t = doc.lex()
 	}
 
 	if t.Type == lexer.EndOfFile {
@@ -384,7 +484,7 @@ func (doc *Parser) next(doNotPush bool) *lexer.Token {
 	}
 
 	lastPhrase := doc.phraseStack[len(doc.phraseStack)-1]
-	if t.Type >= lexer.Comment {
+	if t.Type == lexer.Comment || t.Type == lexer.Whitespace {
 		//hidden token
 		lastPhrase.Children = append(lastPhrase.Children, t)
 
@@ -392,6 +492,7 @@ func (doc *Parser) next(doNotPush bool) *lexer.Token {
 	} else if !doNotPush {
 		lastPhrase.Children = append(lastPhrase.Children, t)
 	}
+	doc.lastOffset = t.Offset
 
 	return t
 }
@@ -475,14 +576,14 @@ func (doc *Parser) peek(n int) *lexer.Token {
 	for {
 		bufferPos++
 		if bufferPos == doc.tokenBuffer.Length() {
-			doc.tokenBuffer.Add(doc.lexerState.Lex())
+			doc.tokenBuffer.Add(doc.lex())
 		}
 		if bufferPos >= doc.tokenBuffer.Length() {
 			return doc.tokenBuffer.Peek()
 		}
 		t = doc.tokenBuffer.Get(bufferPos)
 
-		if t.Type < lexer.Comment {
+		if t.Type != lexer.Comment && t.Type != lexer.Whitespace {
 			//not a hidden token
 			k--
 		}
@@ -512,7 +613,7 @@ func (doc *Parser) skip(predicate func(*lexer.Token) bool) {
 			t = doc.tokenBuffer.Peek()
 			shouldRemove = true
 		} else {
-			t = doc.lexerState.Lex()
+			t = doc.lex()
 			shouldAdd = true
 		}
 
@@ -1248,15 +1349,15 @@ func (doc *Parser) classMemberDeclaration() phrase.AstNode {
 			p.Children = append(p.Children, modifiers)
 
 			return doc.classConstDeclaration(p)
-		} else {
-			typedProperty := doc.typedProperty(p)
-			if typedProperty != nil {
-				return typedProperty
-			}
 		}
 
-		//error
 		p.Children = append(p.Children, modifiers)
+		typedProperty := doc.typedProperty(p)
+		if typedProperty != nil {
+			return typedProperty
+		}
+
+		//error
 		doc.error(lexer.Undefined)
 
 		return doc.end()
@@ -1462,6 +1563,8 @@ func (doc *Parser) methodDeclarationBody() *phrase.Phrase {
 
 	if doc.peek(0).Type == lexer.Semicolon {
 		doc.next(false)
+	} else if doc.opts.LazyBodies && doc.peek(0).Type == lexer.OpenBrace {
+		p.Children = append(p.Children, doc.lazyBody())
 	} else {
 		p.Children = append(p.Children, doc.compoundStatement())
 	}
@@ -1571,6 +1674,13 @@ func (doc *Parser) functionDeclaration() *phrase.Phrase {
 }
 
 func (doc *Parser) functionDeclarationBody() *phrase.Phrase {
+	if doc.opts.LazyBodies && doc.peek(0).Type == lexer.OpenBrace {
+		p := doc.start(phrase.FunctionDeclarationBody, false)
+		p.Children = append(p.Children, doc.lazyBody())
+
+		return doc.end()
+	}
+
 	cs := doc.compoundStatement()
 	cs.Type = phrase.FunctionDeclarationBody
 
@@ -3124,7 +3234,8 @@ func isQualifiedNameStart(t *lexer.Token) bool {
 func (doc *Parser) shortArrayCreationExpression(precedence int) *phrase.Phrase {
 	p := doc.start(phrase.ArrayCreationExpression, false)
 	doc.next(false) //[
-	if isArrayElementStart(doc.peek(0)) || (precedence == 0 && doc.peek(0).Type == lexer.Comma) {
+	if isArrayElementStart(doc.peek(0)) || doc.peek(0).Type == lexer.DocumentCommentStart ||
+		(precedence == 0 && doc.peek(0).Type == lexer.Comma) {
 		p.Children = append(p.Children, doc.arrayInitialiserList(lexer.CloseBracket))
 	}
 	doc.expect(lexer.CloseBracket)
@@ -3137,7 +3248,7 @@ func (doc *Parser) longArrayCreationExpression() *phrase.Phrase {
 	doc.next(false) //array
 	doc.expect(lexer.OpenParenthesis)
 
-	if isArrayElementStart(doc.peek(0)) {
+	if isArrayElementStart(doc.peek(0)) || doc.peek(0).Type == lexer.DocumentCommentStart {
 		p.Children = append(p.Children, doc.arrayInitialiserList(lexer.CloseParenthesis))
 	}
 
@@ -3306,11 +3417,30 @@ func (doc *Parser) haltCompilerStatement() *phrase.Phrase {
 	doc.next(false) // __halt_compiler
 	doc.expect(lexer.OpenParenthesis)
 	doc.expect(lexer.CloseParenthesis)
-	doc.expect(lexer.Semicolon)
+	doc.expectOneOf([]lexer.TokenType{lexer.Semicolon, lexer.CloseTag})
+	doc.optional(lexer.HaltCompilerData)
 
 	return doc.end()
 }
 
+// HaltCompilerOffset returns the offset of the data after __halt_compiler();
+// in the source of root, the value of __COMPILER_HALT_OFFSET__, and whether
+// the source halts the compiler
+func HaltCompilerOffset(root *phrase.Phrase) (int, bool) {
+	for _, child := range root.Children {
+		p, ok := child.(*phrase.Phrase)
+		if !ok || p.Type != phrase.HaltCompilerStatement {
+			continue
+		}
+		for _, c := range p.Children {
+			if t, ok := c.(*lexer.Token); ok && t.Type == lexer.HaltCompilerData {
+				return t.Offset, true
+			}
+		}
+	}
+	return 0, false
+}
+
 func (doc *Parser) namespaceUseDeclaration() *phrase.Phrase {
 	p := doc.start(phrase.NamespaceUseDeclaration, false)
 	doc.next(false) //use
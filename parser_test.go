@@ -1,15 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/bradleyjkemp/cupaloy"
 	"github.com/john-nguyen09/go-phpparser/lexer"
 	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/phrase"
 )
 
 func TestParserAndLexer(t *testing.T) {
@@ -63,27 +70,473 @@ func TestParserAndLexer(t *testing.T) {
 	}
 }
 
+func TestAttachDocComments(t *testing.T) {
+	data, err := ioutil.ReadFile("cases/docCommentAttach.php")
+	if err != nil {
+		panic(err)
+	}
+	rootNode := parser.Parse(data)
+	docs := parser.AttachDocComments(rootNode)
+
+	documented := map[string]string{}
+	var walk func(p *phrase.Phrase)
+	walk = func(p *phrase.Phrase) {
+		if doc, ok := docs[p]; ok {
+			documented[p.Type.String()] = nodeText(data, doc)
+		}
+		for _, child := range p.Children {
+			if child, ok := child.(*phrase.Phrase); ok {
+				walk(child)
+			}
+		}
+	}
+	walk(rootNode)
+
+	// the comments of the array element and of the empty statement document
+	// nothing
+	expected := map[string]string{
+		"ConstDeclaration":      "/** Application constant */",
+		"ClassDeclaration":      "/**\n * @template T\n */",
+		"ClassConstDeclaration": "/** @var int */",
+		"PropertyDeclaration":   "/** @var T[] */",
+		"MethodDeclaration":     "/** Finds an item */",
+		"ExpressionStatement":   "/** @var Repository<User> $users */",
+	}
+	if len(documented) != len(expected) {
+		t.Errorf("expected %d documented phrases, got %v", len(expected), documented)
+	}
+	for phraseType, text := range expected {
+		if documented[phraseType] != text {
+			t.Errorf("%s: expected %q, got %q", phraseType, text, documented[phraseType])
+		}
+	}
+}
+
+func nodeText(data []byte, p *phrase.Phrase) string {
+	first, last := phrase.FirstToken(p), phrase.LastToken(p)
+	return string(data[first.Offset : last.Offset+last.Length])
+}
+
+func TestLazyBodies(t *testing.T) {
+	files, _ := filepath.Glob("cases/*.php")
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		lazy := parser.ParseWithOptions(data, parser.Options{LazyBodies: true})
+		for _, body := range lazyBodies(lazy) {
+			parser.ParseBody(data, body, parser.Options{})
+		}
+		if !reflect.DeepEqual(lazy, parser.Parse(data)) {
+			t.Errorf("%s: parsing the lazy bodies differs from Parse", file)
+		}
+	}
+
+	// unterminated bodies report the same errors as Parse
+	for _, source := range []string{
+		"<?php class A { function f() { $a = 1;",
+		"<?php $f = function() { return 1;",
+		"<?php function f() { if (1) { ",
+	} {
+		data := []byte(source)
+		lazy := parser.ParseWithOptions(data, parser.Options{LazyBodies: true})
+		for _, body := range lazyBodies(lazy) {
+			parser.ParseBody(data, body, parser.Options{})
+		}
+		if !reflect.DeepEqual(lazy, parser.Parse(data)) {
+			t.Errorf("%q: parsing the lazy bodies differs from Parse", source)
+		}
+	}
+
+	data := []byte(`<?php
+function a() { $s = "}{$b}${c}"; /* } */ echo <<<EOT
+} {$d}
+EOT;
+  $f = function () { return '}'; };
+}
+class B { function c() {} }`)
+	root := parser.ParseWithOptions(data, parser.Options{LazyBodies: true})
+	bodies := lazyBodies(root)
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 lazy bodies, got %d", len(bodies))
+	}
+	if text := nodeText(data, bodies[0]); !strings.HasPrefix(text, "{ $s") || !strings.HasSuffix(text, "};\n}") {
+		t.Errorf("unexpected function body %q", text)
+	}
+	if text := nodeText(data, bodies[1]); text != "{}" {
+		t.Errorf("unexpected method body %q", text)
+	}
+	parser.ParseBody(data, bodies[0], parser.Options{})
+	if parser.IsLazy(bodies[0]) || len(lazyBodies(root)) != 1 {
+		t.Errorf("expected the function body to be parsed")
+	}
+}
+
+// lazyBodies returns the lazy bodies of a tree
+func lazyBodies(root *phrase.Phrase) []*phrase.Phrase {
+	var bodies []*phrase.Phrase
+	var walk func(p *phrase.Phrase)
+	walk = func(p *phrase.Phrase) {
+		if parser.IsLazy(p) {
+			bodies = append(bodies, p)
+			return
+		}
+		for _, child := range p.Children {
+			if child, ok := child.(*phrase.Phrase); ok {
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+	return bodies
+}
+
 func BenchmarkParser(b *testing.B) {
-	dir := "cases"
-	files, err := ioutil.ReadDir(dir)
+	files, _ := filepath.Glob("cases/*.php")
+	var sources [][]byte
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		sources = append(sources, data)
+	}
+
+	for _, c := range []struct {
+		name string
+		opts parser.Options
+	}{
+		{"Eager", parser.Options{}},
+		{"LazyBodies", parser.Options{LazyBodies: true}},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, data := range sources {
+					parser.ParseWithOptions(data, c.opts)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFiles(t *testing.T) {
+	paths, _ := filepath.Glob("cases/*.php")
+	paths = append(paths, "cases/missing.php")
+	seen := map[string]bool{}
+	for result := range parser.ParseFiles(context.Background(), paths, parser.Options{Workers: 4}) {
+		seen[result.Path] = true
+		if result.Path == "cases/missing.php" {
+			if !os.IsNotExist(result.Err) {
+				t.Errorf("expected a not exist error, got %v", result.Err)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("%s: %v", result.Path, result.Err)
+			continue
+		}
+		if !reflect.DeepEqual(result.Root, parser.Parse(result.Source)) {
+			t.Errorf("%s: the tree differs from Parse", result.Path)
+		}
+	}
+	if len(seen) != len(paths) {
+		t.Errorf("expected %d results, got %d", len(paths), len(seen))
+	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	count := 0
+	for result := range parser.ParseFiles(ctx, paths, parser.Options{}) {
+		count++
+		if result.Err != context.Canceled || result.Root != nil {
+			t.Errorf("%s: expected the file to be cancelled, got %v", result.Path, result.Err)
+		}
+	}
+	if count != len(paths) {
+		t.Errorf("expected %d results, got %d", len(paths), count)
+	}
+}
+
+func BenchmarkParseFiles(b *testing.B) {
+	dir, err := ioutil.TempDir("", "corpus")
 	if err != nil {
-		panic(err)
+		b.Fatal(err)
 	}
+	defer os.RemoveAll(dir)
+	paths := generateCorpus(b, dir, 1000)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("Workers%d", workers), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for result := range parser.ParseFiles(context.Background(), paths, parser.Options{Workers: workers}) {
+					if result.Err != nil {
+						b.Fatal(result.Err)
+					}
+				}
+			}
+		})
+	}
+}
 
+// generateCorpus writes n PHP files of classes with methods to dir
+func generateCorpus(b *testing.B, dir string, n int) []string {
+	var paths []string
+	for i := 0; i < n; i++ {
+		var source strings.Builder
+		fmt.Fprintf(&source, "<?php\nnamespace App\\Generated;\n\nclass Class%d extends Base\n{\n", i)
+		for j := 0; j < 20; j++ {
+			fmt.Fprintf(&source, `    /** @return array<string, int> */
+    public function method%d(int $a, ?string $b = null): array
+    {
+        $result = [];
+        foreach ($this->items as $key => $item) {
+            if ($item->value > $a && $b !== "skip {$key}") {
+                $result[$key] = $item->value * %d;
+            }
+        }
+        return $result;
+    }
+`, j, j)
+		}
+		source.WriteString("}\n")
+		path := filepath.Join(dir, fmt.Sprintf("Class%d.php", i))
+		if err := ioutil.WriteFile(path, []byte(source.String()), 0644); err != nil {
+			b.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// TestLexerRestart resumes lexing from the state before every token of the
+// cases, lexing a few tokens from each and the rest of the source from the
+// start of every line
+func TestLexerRestart(t *testing.T) {
+	files, _ := filepath.Glob("cases/*.php")
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		l := lexer.NewLexer(data, nil, 0)
+		states := []lexer.State{}
+		tokens := []lexer.Token{}
+		for {
+			states = append(states, l.State())
+			token := l.Lex()
+			tokens = append(tokens, *token)
+			if token.Type == lexer.EndOfFile {
+				break
+			}
+		}
+
+		lineStarts := 0
+		for i, state := range states {
+			n := 4
+			if i > 0 && bytes.HasSuffix(data[:state.Offset()], []byte("\n")) && lineStarts < 50 {
+				lineStarts++
+				n = len(tokens)
+			}
+			restarted := lexer.NewLexerFromState(data, state)
+			for j := i; j < len(tokens) && j < i+n; j++ {
+				if token := restarted.Lex(); *token != tokens[j] {
+					t.Fatalf("%s: restarting at token %d, expected %v at %d, got %v", file, i, tokens[j], j, token)
+				}
+			}
+		}
+
+		// restoring a lexer of the same source
+		l.Restore(states[len(states)/2])
+		if token := l.Lex(); *token != tokens[len(states)/2] {
+			t.Errorf("%s: expected %v after restoring, got %v", file, tokens[len(states)/2], token)
+		}
+	}
+}
+
+func TestLexerStateEdit(t *testing.T) {
+	before := []byte("<?php $a = <<<EOT\nb {$c}\nEOT;\n")
+	// the end of the first line is in the heredoc
+	l := lexer.NewLexer(before, nil, 0)
+	for l.Lex().Type != lexer.StartHeredoc {
+	}
+	state := l.State()
+	if state.Offset() != 18 || !reflect.DeepEqual(state.ModeStack(), []lexer.LexerMode{lexer.ModeHereDoc}) {
+		t.Fatalf("unexpected state at %d in %v", state.Offset(), state.ModeStack())
+	}
+
+	after := []byte("<?php $a = <<<EOT\nxyz\nEOT;\n")
+	actual := []string{}
+	for l := lexer.NewLexerFromState(after, state); ; {
+		token := l.Lex()
+		if token.Type == lexer.EndOfFile {
+			break
+		}
+		actual = append(actual, token.Type.String()+" "+string(token.Text(after)))
+	}
+	expected := []string{"EncapsulatedAndWhitespace xyz", "EndHeredoc \nEOT", "Semicolon ;", "Whitespace \n"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	if !state.Equal(lexer.NewLexerFromState(after, state).State()) {
+		t.Errorf("expected a restored state to equal its origin")
+	}
+
+	// the same modes inside strings opened at different offsets
+	states := []lexer.State{}
+	for _, source := range []string{"<?php \"{$a", "<?php  \"{$a"} {
+		l := lexer.NewLexer([]byte(source), nil, 0)
+		for l.Lex().Type != lexer.VariableName {
+		}
+		states = append(states, l.State())
+	}
+	if !reflect.DeepEqual(states[0].ModeStack(), states[1].ModeStack()) || states[0].Equal(states[1]) {
+		t.Errorf("expected states of strings opened at different offsets to differ")
+	}
+}
+
+func TestLexerMultibyte(t *testing.T) {
+	for _, c := range []struct {
+		source   string
+		expected []string
+	}{
+		{"é<?php $b", []string{"Text é", "OpenTag <?php ", "VariableName $b"}},
+		{"<?php 'x'; é$b", []string{"OpenTag <?php ", "StringLiteral 'x'", "Semicolon ;", "Whitespace  ",
+			"Name é", "VariableName $b"}},
+		{"<?php $日本 = \"語{$日本}\";", []string{"OpenTag <?php ", "VariableName $日本", "Whitespace  ", "Equals =",
+			"Whitespace  ", "DoubleQuote \"", "EncapsulatedAndWhitespace 語", "CurlyOpen {",
+			"VariableName $日本", "CloseBrace }", "DoubleQuote \"", "Semicolon ;"}},
+	} {
+		actual := []string{}
+		for it := lexer.Tokens([]byte(c.source)); it.Next(); {
+			actual = append(actual, it.Token().Type.String()+" "+string(it.Text()))
+		}
+		if strings.Join(actual, "\n") != strings.Join(c.expected, "\n") {
+			t.Errorf("%q: expected %q, got %q", c.source, c.expected, actual)
+		}
+	}
+}
+
+func TestLexerHeredocEnd(t *testing.T) {
+	for _, c := range []struct {
+		source   string
+		expected []string
+	}{
+		{"<?php <<<'EOT'\na\nEOT;\n$b", []string{"OpenTag <?php ", "StartHeredoc <<<'EOT'\n",
+			"EncapsulatedAndWhitespace a", "EndHeredoc \nEOT", "Semicolon ;", "Whitespace \n", "VariableName $b"}},
+		{"<?php <<<'EOT'\r\na\r\nEOT\r\n", []string{"OpenTag <?php ", "StartHeredoc <<<'EOT'\r\n",
+			"EncapsulatedAndWhitespace a", "EndHeredoc \r\nEOT", "Whitespace \r\n"}},
+		{"<?php <<<'EOT'\na\nEOTX\nEOT", []string{"OpenTag <?php ", "StartHeredoc <<<'EOT'\n",
+			"EncapsulatedAndWhitespace a\nEOTX", "EndHeredoc \nEOT"}},
+		{"<?php <<<EOT\na\nEOT", []string{"OpenTag <?php ", "StartHeredoc <<<EOT\n",
+			"EncapsulatedAndWhitespace a", "EndHeredoc \nEOT"}},
+	} {
+		l := lexer.NewLexer([]byte(c.source), nil, 0)
+		actual := []string{}
+		for t := l.Lex(); t.Type != lexer.EndOfFile; t = l.Lex() {
+			actual = append(actual, t.Type.String()+" "+c.source[t.Offset:t.Offset+t.Length])
+		}
+		if strings.Join(actual, "\n") != strings.Join(c.expected, "\n") {
+			t.Errorf("%q: expected %q, got %q", c.source, c.expected, actual)
+		}
+	}
+}
+
+func BenchmarkLexer(b *testing.B) {
+	files, _ := filepath.Glob("cases/*.php")
+	var sources [][]byte
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		sources = append(sources, data)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		for _, file := range files {
-			if !strings.HasSuffix(file.Name(), ".php") {
-				continue
+		for _, data := range sources {
+			l := lexer.NewLexer(data, nil, 0)
+			for l.Lex().Type != lexer.EndOfFile {
 			}
+		}
+	}
+}
 
-			filePath := dir + "/" + file.Name()
-			data, err := ioutil.ReadFile(filePath)
+func TestParseReader(t *testing.T) {
+	files, _ := filepath.Glob("cases/*.php")
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		expected := parser.Parse(data)
+		for _, bufferSize := range []int{1, 7, 4096} {
+			actual, err := parser.ParseReader(iotest.HalfReader(bytes.NewReader(data)), parser.Options{}, bufferSize)
 			if err != nil {
-				panic(err)
+				t.Fatalf("%s: %v", file, err)
 			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("%s: the tree differs from Parse with a buffer of %d bytes", file, bufferSize)
+			}
+		}
+	}
+
+	_, err := parser.ParseReader(iotest.TimeoutReader(strings.NewReader("<?php echo 1;")), parser.Options{}, 4)
+	if err != iotest.ErrTimeout {
+		t.Errorf("expected the read error, got %v", err)
+	}
+}
+
+func TestUnterminatedDocComment(t *testing.T) {
+	for _, source := range []string{
+		"<?php /** @a <",
+		"<?php /** @author a",
+		"<?php /** @author a <b",
+		"<?php /** @author a */ $c;",
+		"<?php /** @method d($e = ",
+	} {
+		root, diagnostics := parser.ParseWithDiagnostics([]byte(source), parser.Options{})
+		if last := phrase.LastToken(root); last == nil || last.Offset+last.Length != len(source) {
+			t.Errorf("%q: expected the tree to cover the source", source)
+		}
+		unterminated := len(diagnostics) == 1 && diagnostics[0].Kind == lexer.UnterminatedComment
+		if unterminated != !strings.Contains(source, "*/") {
+			t.Errorf("%q: unexpected diagnostics %v", source, diagnostics)
+		}
+	}
+}
 
-			parser.Parse(data)
+func TestHaltCompilerOffset(t *testing.T) {
+	for source, expected := range map[string]int{
+		"<?php\n__halt_compiler();\x00\xff<?php }": 24,
+		"<?php __halt_compiler() ?>\r\n<?php }":    28,
+		"<?php if ($a) {}\n__HALT_COMPILER ( ) ;":  38,
+	} {
+		root, diagnostics := parser.ParseWithDiagnostics([]byte(source), parser.Options{})
+		if offset, ok := parser.HaltCompilerOffset(root); !ok || offset != expected {
+			t.Errorf("%q: expected the data at %d, got %d %v", source, expected, offset, ok)
+		}
+		if hasParseError(root) || len(diagnostics) > 0 {
+			t.Errorf("%q: unexpected errors %v", source, diagnostics)
+		}
+	}
+	if _, ok := parser.HaltCompilerOffset(parser.Parse([]byte("<?php __halt_compiler"))); ok {
+		t.Errorf("expected no data without __halt_compiler();")
+	}
+}
+
+func hasParseError(node phrase.AstNode) bool {
+	switch node := node.(type) {
+	case *phrase.ParseError:
+		return true
+	case *phrase.Phrase:
+		for _, child := range node.Children {
+			if hasParseError(child) {
+				return true
+			}
 		}
 	}
+	return false
 }
@@ -199,6 +199,10 @@ const (
 	DocumentCommentDescription
 	DocumentCommentAuthor
 	DocumentCommentEmail
+	DocumentCommentParagraph
+	DocumentCommentInlineTag
+	DocumentCommentReference
+	DocumentCommentCodeSpan
 
 	DocumentCommentTagAnchorStart
 	DocumentCommentTag
@@ -211,10 +215,26 @@ const (
 	DocumentCommentReturnTag
 	DocumentCommentThrowsTag
 	DocumentCommentVarTag
+	DocumentCommentTemplateTag
+	DocumentCommentExtendsTag
+	DocumentCommentImplementsTag
+	DocumentCommentUseTag
+	DocumentCommentMixinTag
+	DocumentCommentParamOutTag
+	DocumentCommentAssertTag
 	DocumentCommentTagAnchorEnd
 
 	TypeUnion
 	ParameterValue
+
+	TypeIntersection
+	TypeConditional
+	TypeArgumentList
+	TypeArgument
+	TypeArrayShape
+	TypeArrayShapeItem
+
+	LazyBody
 )
 
 //go:generate stringer -type=PhraseType
@@ -246,6 +266,20 @@ func (phraseType *PhraseType) MarshalJSON() ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+var phraseTypesByName = map[string]PhraseType{}
+
+func init() {
+	for t := PhraseType(0); int(t) < len(_PhraseType_index)-1; t++ {
+		phraseTypesByName[t.String()] = t
+	}
+}
+
+// PhraseTypeByName returns the phrase type whose String() is name
+func PhraseTypeByName(name string) (PhraseType, bool) {
+	t, ok := phraseTypesByName[name]
+	return t, ok
+}
+
 func NewPhrase(pool *Pool, phraseType PhraseType, children []AstNode) *Phrase {
 	p := pool.Get()
 	p.Type = phraseType
@@ -263,3 +297,53 @@ func NewParseErr(
 
 	return &ParseError{*phrase, unexpected, expected}
 }
+
+// FirstToken returns the first token of node, ignoring whitespace and comments
+func FirstToken(node AstNode) *lexer.Token {
+	switch node := node.(type) {
+	case *lexer.Token:
+		if node.Type == lexer.Comment || node.Type == lexer.Whitespace {
+			return nil
+		}
+		return node
+	case *Phrase:
+		return firstToken(node.Children)
+	case *ParseError:
+		return firstToken(node.Children)
+	}
+	return nil
+}
+
+// LastToken returns the last token of node, ignoring whitespace and comments
+func LastToken(node AstNode) *lexer.Token {
+	switch node := node.(type) {
+	case *lexer.Token:
+		if node.Type == lexer.Comment || node.Type == lexer.Whitespace {
+			return nil
+		}
+		return node
+	case *Phrase:
+		return lastToken(node.Children)
+	case *ParseError:
+		return lastToken(node.Children)
+	}
+	return nil
+}
+
+func firstToken(children []AstNode) *lexer.Token {
+	for _, child := range children {
+		if t := FirstToken(child); t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+func lastToken(children []AstNode) *lexer.Token {
+	for i := len(children) - 1; i >= 0; i-- {
+		if t := LastToken(children[i]); t != nil {
+			return t
+		}
+	}
+	return nil
+}
@@ -198,25 +198,43 @@ func _() {
 	_ = x[DocumentCommentDescription-187]
 	_ = x[DocumentCommentAuthor-188]
 	_ = x[DocumentCommentEmail-189]
-	_ = x[DocumentCommentTagAnchorStart-190]
-	_ = x[DocumentCommentTag-191]
-	_ = x[DocumentCommentAuthorTag-192]
-	_ = x[DocumentCommentDeprecatedTag-193]
-	_ = x[DocumentCommentGlobalTag-194]
-	_ = x[DocumentCommentMethodTag-195]
-	_ = x[DocumentCommentParamTag-196]
-	_ = x[DocumentCommentPropertyTag-197]
-	_ = x[DocumentCommentReturnTag-198]
-	_ = x[DocumentCommentThrowsTag-199]
-	_ = x[DocumentCommentVarTag-200]
-	_ = x[DocumentCommentTagAnchorEnd-201]
-	_ = x[TypeUnion-202]
-	_ = x[ParameterValue-203]
+	_ = x[DocumentCommentParagraph-190]
+	_ = x[DocumentCommentInlineTag-191]
+	_ = x[DocumentCommentReference-192]
+	_ = x[DocumentCommentCodeSpan-193]
+	_ = x[DocumentCommentTagAnchorStart-194]
+	_ = x[DocumentCommentTag-195]
+	_ = x[DocumentCommentAuthorTag-196]
+	_ = x[DocumentCommentDeprecatedTag-197]
+	_ = x[DocumentCommentGlobalTag-198]
+	_ = x[DocumentCommentMethodTag-199]
+	_ = x[DocumentCommentParamTag-200]
+	_ = x[DocumentCommentPropertyTag-201]
+	_ = x[DocumentCommentReturnTag-202]
+	_ = x[DocumentCommentThrowsTag-203]
+	_ = x[DocumentCommentVarTag-204]
+	_ = x[DocumentCommentTemplateTag-205]
+	_ = x[DocumentCommentExtendsTag-206]
+	_ = x[DocumentCommentImplementsTag-207]
+	_ = x[DocumentCommentUseTag-208]
+	_ = x[DocumentCommentMixinTag-209]
+	_ = x[DocumentCommentParamOutTag-210]
+	_ = x[DocumentCommentAssertTag-211]
+	_ = x[DocumentCommentTagAnchorEnd-212]
+	_ = x[TypeUnion-213]
+	_ = x[ParameterValue-214]
+	_ = x[TypeIntersection-215]
+	_ = x[TypeConditional-216]
+	_ = x[TypeArgumentList-217]
+	_ = x[TypeArgument-218]
+	_ = x[TypeArrayShape-219]
+	_ = x[TypeArrayShapeItem-220]
+	_ = x[LazyBody-221]
 }
 
-const _PhraseType_name = "UnknownAdditiveExpressionAnonymousClassDeclarationAnonymousClassDeclarationHeaderAnonymousFunctionCreationExpressionAnonymousFunctionHeaderAnonymousFunctionUseClauseAnonymousFunctionUseVariableArrowFunctionCreationExpressionArrowFunctionHeaderArrowFunctionUseClauseArrowFunctionUseVariableArgumentExpressionListArrayCreationExpressionArrayElementArrayInitialiserListArrayKeyArrayValueBitwiseExpressionBreakStatementByRefAssignmentExpressionCaseStatementCaseStatementListCastExpressionCatchClauseCatchClauseListCatchNameListClassBaseClauseClassConstantAccessExpressionClassConstDeclarationClassConstElementClassConstElementListClassDeclarationClassDeclarationBodyClassDeclarationHeaderClassInterfaceClauseClassMemberDeclarationListClassModifiersClassTypeDesignatorCloneExpressionClosureUseListCoalesceExpressionCompoundAssignmentExpressionCompoundStatementTernaryExpressionConstantAccessExpressionConstDeclarationConstElementConstElementListContinueStatementDeclareDirectiveDeclareStatementDefaultStatementDoStatementDoubleQuotedStringLiteralEchoIntrinsicElseClauseElseIfClauseElseIfClauseListEmptyIntrinsicEncapsulatedExpressionEncapsulatedVariableEncapsulatedVariableListEqualityExpressionErrorErrorClassMemberDeclarationErrorClassTypeDesignatorAtomErrorControlExpressionErrorExpressionErrorScopedAccessExpressionErrorTraitAdaptationErrorVariableErrorVariableAtomEvalIntrinsicExitIntrinsicExponentiationExpressionExpressionListExpressionStatementFinallyClauseForControlForeachCollectionForeachKeyForeachStatementForeachValueForEndOfLoopForExpressionGroupForInitialiserForStatementFullyQualifiedNameFunctionCallExpressionFunctionDeclarationFunctionDeclarationBodyFunctionDeclarationHeaderFunctionStaticDeclarationFunctionStaticInitialiserGlobalDeclarationGotoStatementHaltCompilerStatementHeredocStringLiteralIdentifierIfStatementIncludeExpressionIncludeOnceExpressionInlineTextInstanceOfExpressionInstanceofTypeDesignatorInterfaceBaseClauseInterfaceDeclarationInterfaceDeclarationBodyInterfaceDeclarationHeaderInterfaceMemberDeclarationListIssetIntrinsicListIntrinsicLogicalExpressionMemberModifierListMemberNameMethodCallExpressionMethodDeclarationMethodDeclarationBodyMethodDeclarationHeaderMethodReferenceMultiplicativeExpressionNamedLabelStatementNamespaceAliasingClauseNamespaceDefinitionNamespaceNameNamespaceUseClauseNamespaceUseClauseListNamespaceUseDeclarationNamespaceUseGroupClauseNamespaceUseGroupClauseListNullStatementObjectCreationExpressionParameterDeclarationParameterDeclarationListPostfixDecrementExpressionPostfixIncrementExpressionPrefixDecrementExpressionPrefixIncrementExpressionPrintIntrinsicPropertyAccessExpressionPropertyDeclarationPropertyElementPropertyElementListPropertyInitialiserQualifiedNameQualifiedNameListRelationalExpressionRelativeQualifiedNameRelativeScopeRequireExpressionRequireOnceExpressionReturnStatementReturnTypeScopedCallExpressionScopedMemberNameScopedPropertyAccessExpressionShellCommandExpressionShiftExpressionSimpleAssignmentExpressionSimpleVariableStatementListStaticVariableDeclarationStaticVariableDeclarationListSubscriptExpressionSwitchStatementThrowStatementTraitAdaptationListTraitAliasTraitDeclarationTraitDeclarationBodyTraitDeclarationHeaderTraitMemberDeclarationListTraitPrecedenceTraitUseClauseTraitUseSpecificationTryStatementTypeDeclarationUnaryOpExpressionUnsetIntrinsicVariableListVariableNameListVariadicUnpackingWhileStatementYieldExpressionYieldFromExpressionDocumentCommentDocumentCommentDescriptionDocumentCommentAuthorDocumentCommentEmailDocumentCommentTagAnchorStartDocumentCommentTagDocumentCommentAuthorTagDocumentCommentDeprecatedTagDocumentCommentGlobalTagDocumentCommentMethodTagDocumentCommentParamTagDocumentCommentPropertyTagDocumentCommentReturnTagDocumentCommentThrowsTagDocumentCommentVarTagDocumentCommentTagAnchorEndTypeUnionParameterValue"
+const _PhraseType_name = "UnknownAdditiveExpressionAnonymousClassDeclarationAnonymousClassDeclarationHeaderAnonymousFunctionCreationExpressionAnonymousFunctionHeaderAnonymousFunctionUseClauseAnonymousFunctionUseVariableArrowFunctionCreationExpressionArrowFunctionHeaderArrowFunctionUseClauseArrowFunctionUseVariableArgumentExpressionListArrayCreationExpressionArrayElementArrayInitialiserListArrayKeyArrayValueBitwiseExpressionBreakStatementByRefAssignmentExpressionCaseStatementCaseStatementListCastExpressionCatchClauseCatchClauseListCatchNameListClassBaseClauseClassConstantAccessExpressionClassConstDeclarationClassConstElementClassConstElementListClassDeclarationClassDeclarationBodyClassDeclarationHeaderClassInterfaceClauseClassMemberDeclarationListClassModifiersClassTypeDesignatorCloneExpressionClosureUseListCoalesceExpressionCompoundAssignmentExpressionCompoundStatementTernaryExpressionConstantAccessExpressionConstDeclarationConstElementConstElementListContinueStatementDeclareDirectiveDeclareStatementDefaultStatementDoStatementDoubleQuotedStringLiteralEchoIntrinsicElseClauseElseIfClauseElseIfClauseListEmptyIntrinsicEncapsulatedExpressionEncapsulatedVariableEncapsulatedVariableListEqualityExpressionErrorErrorClassMemberDeclarationErrorClassTypeDesignatorAtomErrorControlExpressionErrorExpressionErrorScopedAccessExpressionErrorTraitAdaptationErrorVariableErrorVariableAtomEvalIntrinsicExitIntrinsicExponentiationExpressionExpressionListExpressionStatementFinallyClauseForControlForeachCollectionForeachKeyForeachStatementForeachValueForEndOfLoopForExpressionGroupForInitialiserForStatementFullyQualifiedNameFunctionCallExpressionFunctionDeclarationFunctionDeclarationBodyFunctionDeclarationHeaderFunctionStaticDeclarationFunctionStaticInitialiserGlobalDeclarationGotoStatementHaltCompilerStatementHeredocStringLiteralIdentifierIfStatementIncludeExpressionIncludeOnceExpressionInlineTextInstanceOfExpressionInstanceofTypeDesignatorInterfaceBaseClauseInterfaceDeclarationInterfaceDeclarationBodyInterfaceDeclarationHeaderInterfaceMemberDeclarationListIssetIntrinsicListIntrinsicLogicalExpressionMemberModifierListMemberNameMethodCallExpressionMethodDeclarationMethodDeclarationBodyMethodDeclarationHeaderMethodReferenceMultiplicativeExpressionNamedLabelStatementNamespaceAliasingClauseNamespaceDefinitionNamespaceNameNamespaceUseClauseNamespaceUseClauseListNamespaceUseDeclarationNamespaceUseGroupClauseNamespaceUseGroupClauseListNullStatementObjectCreationExpressionParameterDeclarationParameterDeclarationListPostfixDecrementExpressionPostfixIncrementExpressionPrefixDecrementExpressionPrefixIncrementExpressionPrintIntrinsicPropertyAccessExpressionPropertyDeclarationPropertyElementPropertyElementListPropertyInitialiserQualifiedNameQualifiedNameListRelationalExpressionRelativeQualifiedNameRelativeScopeRequireExpressionRequireOnceExpressionReturnStatementReturnTypeScopedCallExpressionScopedMemberNameScopedPropertyAccessExpressionShellCommandExpressionShiftExpressionSimpleAssignmentExpressionSimpleVariableStatementListStaticVariableDeclarationStaticVariableDeclarationListSubscriptExpressionSwitchStatementThrowStatementTraitAdaptationListTraitAliasTraitDeclarationTraitDeclarationBodyTraitDeclarationHeaderTraitMemberDeclarationListTraitPrecedenceTraitUseClauseTraitUseSpecificationTryStatementTypeDeclarationUnaryOpExpressionUnsetIntrinsicVariableListVariableNameListVariadicUnpackingWhileStatementYieldExpressionYieldFromExpressionDocumentCommentDocumentCommentDescriptionDocumentCommentAuthorDocumentCommentEmailDocumentCommentParagraphDocumentCommentInlineTagDocumentCommentReferenceDocumentCommentCodeSpanDocumentCommentTagAnchorStartDocumentCommentTagDocumentCommentAuthorTagDocumentCommentDeprecatedTagDocumentCommentGlobalTagDocumentCommentMethodTagDocumentCommentParamTagDocumentCommentPropertyTagDocumentCommentReturnTagDocumentCommentThrowsTagDocumentCommentVarTagDocumentCommentTemplateTagDocumentCommentExtendsTagDocumentCommentImplementsTagDocumentCommentUseTagDocumentCommentMixinTagDocumentCommentParamOutTagDocumentCommentAssertTagDocumentCommentTagAnchorEndTypeUnionParameterValueTypeIntersectionTypeConditionalTypeArgumentListTypeArgumentTypeArrayShapeTypeArrayShapeItemLazyBody"
 
-var _PhraseType_index = [...]uint16{0, 7, 25, 50, 81, 116, 139, 165, 193, 224, 243, 265, 289, 311, 334, 346, 366, 374, 384, 401, 415, 440, 453, 470, 484, 495, 510, 523, 538, 567, 588, 605, 626, 642, 662, 684, 704, 730, 744, 763, 778, 792, 810, 838, 855, 872, 896, 912, 924, 940, 957, 973, 989, 1005, 1016, 1041, 1054, 1064, 1076, 1092, 1106, 1128, 1148, 1172, 1190, 1195, 1222, 1250, 1272, 1287, 1314, 1334, 1347, 1364, 1377, 1390, 1414, 1428, 1447, 1460, 1470, 1487, 1497, 1513, 1525, 1537, 1555, 1569, 1581, 1599, 1621, 1640, 1663, 1688, 1713, 1738, 1755, 1768, 1789, 1809, 1819, 1830, 1847, 1868, 1878, 1898, 1922, 1941, 1961, 1985, 2011, 2041, 2055, 2068, 2085, 2103, 2113, 2133, 2150, 2171, 2194, 2209, 2233, 2252, 2275, 2294, 2307, 2325, 2347, 2370, 2393, 2420, 2433, 2457, 2477, 2501, 2527, 2553, 2578, 2603, 2617, 2641, 2660, 2675, 2694, 2713, 2726, 2743, 2763, 2784, 2797, 2814, 2835, 2850, 2860, 2880, 2896, 2926, 2948, 2963, 2989, 3003, 3016, 3041, 3070, 3089, 3104, 3118, 3137, 3147, 3163, 3183, 3205, 3231, 3246, 3260, 3281, 3293, 3308, 3325, 3339, 3351, 3367, 3384, 3398, 3413, 3432, 3447, 3473, 3494, 3514, 3543, 3561, 3585, 3613, 3637, 3661, 3684, 3710, 3734, 3758, 3779, 3806, 3815, 3829}
+var _PhraseType_index = [...]uint16{0, 7, 25, 50, 81, 116, 139, 165, 193, 224, 243, 265, 289, 311, 334, 346, 366, 374, 384, 401, 415, 440, 453, 470, 484, 495, 510, 523, 538, 567, 588, 605, 626, 642, 662, 684, 704, 730, 744, 763, 778, 792, 810, 838, 855, 872, 896, 912, 924, 940, 957, 973, 989, 1005, 1016, 1041, 1054, 1064, 1076, 1092, 1106, 1128, 1148, 1172, 1190, 1195, 1222, 1250, 1272, 1287, 1314, 1334, 1347, 1364, 1377, 1390, 1414, 1428, 1447, 1460, 1470, 1487, 1497, 1513, 1525, 1537, 1555, 1569, 1581, 1599, 1621, 1640, 1663, 1688, 1713, 1738, 1755, 1768, 1789, 1809, 1819, 1830, 1847, 1868, 1878, 1898, 1922, 1941, 1961, 1985, 2011, 2041, 2055, 2068, 2085, 2103, 2113, 2133, 2150, 2171, 2194, 2209, 2233, 2252, 2275, 2294, 2307, 2325, 2347, 2370, 2393, 2420, 2433, 2457, 2477, 2501, 2527, 2553, 2578, 2603, 2617, 2641, 2660, 2675, 2694, 2713, 2726, 2743, 2763, 2784, 2797, 2814, 2835, 2850, 2860, 2880, 2896, 2926, 2948, 2963, 2989, 3003, 3016, 3041, 3070, 3089, 3104, 3118, 3137, 3147, 3163, 3183, 3205, 3231, 3246, 3260, 3281, 3293, 3308, 3325, 3339, 3351, 3367, 3384, 3398, 3413, 3432, 3447, 3473, 3494, 3514, 3538, 3562, 3586, 3609, 3638, 3656, 3680, 3708, 3732, 3756, 3779, 3805, 3829, 3853, 3874, 3900, 3925, 3953, 3974, 3997, 4023, 4047, 4074, 4083, 4097, 4113, 4128, 4144, 4156, 4170, 4188, 4196}
 
 func (i PhraseType) String() string {
 	if i >= PhraseType(len(_PhraseType_index)-1) {
@@ -2,6 +2,8 @@ package phrase
 
 const DefaultBlockSize = 1024
 
+// Pool allocates phrases from blocks. Like lexer.Pool, it can serve several
+// parses one after another but is not safe for concurrent use.
 type Pool struct {
 	block []Phrase
 	off   int
@@ -0,0 +1,111 @@
+// Package position converts byte offsets, as used by lexer.Token, to zero-based
+// line and character positions, counting characters in UTF-16 code units as
+// the Language Server Protocol does.
+package position
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Position is a zero-based line and UTF-16 character offset
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Index maps between byte offsets and positions of a source
+type Index struct {
+	source     []byte
+	lineStarts []int
+}
+
+func NewIndex(source []byte) *Index {
+	lineStarts := []int{0}
+	for i := 0; i < len(source); i++ {
+		switch source[i] {
+		case '\r':
+			if i+1 < len(source) && source[i+1] == '\n' {
+				i++
+			}
+			lineStarts = append(lineStarts, i+1)
+		case '\n':
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return &Index{source, lineStarts}
+}
+
+// LineCount returns the number of lines, an empty source having one
+func (idx *Index) LineCount() int {
+	return len(idx.lineStarts)
+}
+
+// Line returns the zero-based line containing offset
+func (idx *Index) Line(offset int) int {
+	return sort.Search(len(idx.lineStarts), func(i int) bool {
+		return idx.lineStarts[i] > offset
+	}) - 1
+}
+
+// LineStart returns the byte offset at which line starts
+func (idx *Index) LineStart(line int) int {
+	if line < 0 {
+		return 0
+	}
+	if line >= len(idx.lineStarts) {
+		return len(idx.source)
+	}
+	return idx.lineStarts[line]
+}
+
+// Position converts a byte offset, clamped to the source
+func (idx *Index) Position(offset int) Position {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(idx.source) {
+		offset = len(idx.source)
+	}
+	line := idx.Line(offset)
+	return Position{line, utf16Length(idx.source[idx.lineStarts[line]:offset])}
+}
+
+// Offset converts a position to a byte offset. Characters past the end of the
+// line are clamped to the line end.
+func (idx *Index) Offset(p Position) int {
+	if p.Line < 0 {
+		return 0
+	}
+	if p.Line >= len(idx.lineStarts) {
+		return len(idx.source)
+	}
+	offset := idx.lineStarts[p.Line]
+	for character := 0; offset < len(idx.source) && character < p.Character; {
+		c := idx.source[offset]
+		if c == '\r' || c == '\n' {
+			break
+		}
+		r, size := utf8.DecodeRune(idx.source[offset:])
+		character += utf16RuneLength(r)
+		offset += size
+	}
+	return offset
+}
+
+func utf16Length(b []byte) int {
+	n := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		n += utf16RuneLength(r)
+		b = b[size:]
+	}
+	return n
+}
+
+func utf16RuneLength(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
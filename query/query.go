@@ -0,0 +1,426 @@
+// Package query implements structural search with patterns written as PHP
+// code.
+//
+// A pattern is an expression such as $X->setAccessible(true) or one or more
+// statements. Variables named in upper case, like $X or $CLASS, are
+// metavariables: they match any single node and bind it, and every occurrence
+// of the same metavariable must match equal code. In argument, array and
+// statement lists, ... matches any number of elements and ...$ARGS binds them.
+// Whitespace and comments are ignored.
+package query
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+// ErrInvalidPattern is returned when a pattern is not valid PHP
+var ErrInvalidPattern = errors.New("query: pattern is not valid PHP")
+
+// ellipsis is the variable ... is rewritten to before parsing a pattern
+const ellipsis = "$___"
+
+var metavariable = regexp.MustCompile(`^\$[A-Z][A-Z0-9_]*$`)
+
+// Pattern is a compiled pattern
+type Pattern struct {
+	source []byte
+	// nodes is a single expression or statement, or a sequence of statements
+	nodes []phrase.AstNode
+}
+
+// Binding is the code bound to a metavariable, Start and End being the byte
+// offsets of its nodes. An empty binding of ...$ARGS has no nodes and Start
+// and End equal to -1.
+type Binding struct {
+	Nodes []phrase.AstNode
+	Start int
+	End   int
+}
+
+// Text returns the bound code
+func (b Binding) Text(source []byte) string {
+	if b.Start < 0 {
+		return ""
+	}
+	return string(source[b.Start:b.End])
+}
+
+// Match is a match of a pattern, Nodes being the matched expression or
+// statements
+type Match struct {
+	Nodes    []phrase.AstNode
+	Start    int
+	End      int
+	Bindings map[string]Binding
+}
+
+// Compile parses a pattern
+func Compile(pattern string) (*Pattern, error) {
+	source := rewriteEllipses([]byte("<?php " + pattern))
+	trimmed := bytes.TrimSpace(source)
+	if len(trimmed) > 0 && trimmed[len(trimmed)-1] != ';' {
+		// an expression, unless it only parses as statements such as if () {}
+		expression := append(append([]byte{}, source...), ';')
+		if statements, ok := statements(expression); ok && len(statements) == 1 {
+			if statement, ok := statements[0].(*phrase.Phrase); ok && statement.Type == phrase.ExpressionStatement {
+				return &Pattern{expression, significant(statement)[:1]}, nil
+			}
+		}
+	}
+	nodes, ok := statements(source)
+	if !ok || len(nodes) == 0 {
+		return nil, ErrInvalidPattern
+	}
+	return &Pattern{source, nodes}, nil
+}
+
+// MustCompile is like Compile but panics if the pattern is invalid
+func MustCompile(pattern string) *Pattern {
+	p, err := Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// rewriteEllipses replaces each ... that is not followed by a variable with
+// $___, followed by a semicolon where it stands for statements
+func rewriteEllipses(source []byte) []byte {
+	var tokens []*lexer.Token
+	l := lexer.NewLexer(source, nil, 0)
+	for t := l.Lex(); t.Type != lexer.EndOfFile; t = l.Lex() {
+		if t.Type != lexer.Comment && t.Type != lexer.Whitespace {
+			tokens = append(tokens, t)
+		}
+	}
+
+	var result []byte
+	offset := 0
+	for i, t := range tokens {
+		if t.Type != lexer.Ellipsis || i+1 < len(tokens) && tokens[i+1].Type == lexer.VariableName {
+			continue
+		}
+		result = append(result, source[offset:t.Offset]...)
+		result = append(result, ellipsis...)
+		switch tokens[i-1].Type {
+		case lexer.OpenParenthesis, lexer.OpenBracket, lexer.Comma:
+		default:
+			result = append(result, ';')
+		}
+		offset = t.Offset + t.Length
+	}
+	return append(result, source[offset:]...)
+}
+
+// statements parses source and returns its statements, reporting whether it
+// parsed without errors
+func statements(source []byte) ([]phrase.AstNode, bool) {
+	root := parser.Parse(source)
+	if hasErrors(root) {
+		return nil, false
+	}
+	var result []phrase.AstNode
+	for _, child := range significant(root) {
+		if p, ok := child.(*phrase.Phrase); ok && p.Type == phrase.InlineText {
+			continue
+		}
+		result = append(result, child)
+	}
+	return result, true
+}
+
+func hasErrors(node phrase.AstNode) bool {
+	switch node := node.(type) {
+	case *phrase.ParseError:
+		return true
+	case *phrase.Phrase:
+		for _, child := range node.Children {
+			if hasErrors(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// significant returns the children that take part in matching, leaving out
+// whitespace, comments and commas
+func significant(node phrase.AstNode) []phrase.AstNode {
+	var children []phrase.AstNode
+	switch node := node.(type) {
+	case *phrase.Phrase:
+		children = node.Children
+	case *phrase.ParseError:
+		children = node.Children
+	}
+	var result []phrase.AstNode
+	for _, child := range children {
+		switch child := child.(type) {
+		case *lexer.Token:
+			if child.Type == lexer.Comment || child.Type == lexer.Whitespace || child.Type == lexer.Comma {
+				continue
+			}
+		case *phrase.Phrase:
+			if child.Type == phrase.DocumentComment {
+				continue
+			}
+		}
+		result = append(result, child)
+	}
+	return result
+}
+
+// Match returns the matches of the pattern in the tree of source, outer
+// matches first
+func (p *Pattern) Match(root *phrase.Phrase, source []byte) []Match {
+	m := &matcher{pattern: p.source, source: source}
+	var result []Match
+	var visit func(node phrase.AstNode)
+	visit = func(node phrase.AstNode) {
+		if len(p.nodes) == 1 {
+			if b, ok := m.node(p.nodes[0], node, bindings{}); ok {
+				result = append(result, m.match([]phrase.AstNode{node}, b))
+			}
+		} else {
+			children := significant(node)
+			for i := range children {
+				if b, n, ok := m.sequence(p.nodes, children[i:], bindings{}, true); ok && n > 0 {
+					result = append(result, m.match(children[i:i+n], b))
+				}
+			}
+		}
+		var children []phrase.AstNode
+		switch node := node.(type) {
+		case *phrase.Phrase:
+			children = node.Children
+		case *phrase.ParseError:
+			children = node.Children
+		}
+		for _, child := range children {
+			visit(child)
+		}
+	}
+	visit(root)
+	return result
+}
+
+type bindings map[string][]phrase.AstNode
+
+func (b bindings) with(name string, nodes []phrase.AstNode) bindings {
+	result := bindings{name: nodes}
+	for k, v := range b {
+		result[k] = v
+	}
+	return result
+}
+
+type matcher struct {
+	pattern []byte
+	source  []byte
+}
+
+func (m *matcher) match(nodes []phrase.AstNode, b bindings) Match {
+	result := Match{Nodes: nodes, Bindings: map[string]Binding{}}
+	result.Start, result.End = extent(nodes)
+	for name, nodes := range b {
+		binding := Binding{Nodes: nodes}
+		binding.Start, binding.End = extent(nodes)
+		result.Bindings[name] = binding
+	}
+	return result
+}
+
+func extent(nodes []phrase.AstNode) (int, int) {
+	start, end := -1, -1
+	for _, node := range nodes {
+		first, last := phrase.FirstToken(node), phrase.LastToken(node)
+		if first == nil {
+			continue
+		}
+		if start < 0 {
+			start = first.Offset
+		}
+		end = last.Offset + last.Length
+	}
+	return start, end
+}
+
+// variable returns the name of the variable a pattern node consists of,
+// looking through phrases with a single significant child
+func (m *matcher) variable(node phrase.AstNode) string {
+	for {
+		switch n := node.(type) {
+		case *lexer.Token:
+			if n.Type != lexer.VariableName {
+				return ""
+			}
+			return string(m.pattern[n.Offset : n.Offset+n.Length])
+		case *phrase.Phrase:
+			children := significant(n)
+			if n.Type == phrase.ExpressionStatement && len(children) == 2 {
+				// $___; standing for statements
+				children = children[:1]
+			}
+			if len(children) != 1 {
+				return ""
+			}
+			node = children[0]
+		default:
+			return ""
+		}
+	}
+}
+
+// variadic returns the name of the metavariable of a ...$NAME pattern node
+func (m *matcher) variadic(node phrase.AstNode) string {
+	p, ok := node.(*phrase.Phrase)
+	if !ok || p.Type != phrase.VariadicUnpacking {
+		return ""
+	}
+	children := significant(p)
+	if len(children) != 2 {
+		return ""
+	}
+	if name := m.variable(children[1]); metavariable.MatchString(name) {
+		return name
+	}
+	return ""
+}
+
+// node matches a pattern node against a node of the source
+func (m *matcher) node(pattern, node phrase.AstNode, b bindings) (bindings, bool) {
+	if name := m.metavariable(pattern); name != "" {
+		if t, ok := pattern.(*lexer.Token); ok {
+			// a bare variable name only matches a variable name, e.g. a parameter
+			if n, ok := node.(*lexer.Token); !ok || n.Type != t.Type {
+				return nil, false
+			}
+		}
+		return m.bind(name, []phrase.AstNode{node}, b)
+	}
+
+	switch p := pattern.(type) {
+	case *lexer.Token:
+		t, ok := node.(*lexer.Token)
+		if !ok || t.Type != p.Type {
+			return nil, false
+		}
+		return b, sameText(p.Type, m.pattern[p.Offset:p.Offset+p.Length], m.source[t.Offset:t.Offset+t.Length])
+	case *phrase.Phrase:
+		n, ok := node.(*phrase.Phrase)
+		if !ok || n.Type != p.Type {
+			return nil, false
+		}
+		b, _, ok = m.sequence(significant(p), significant(n), b, false)
+		return b, ok
+	}
+	return nil, false
+}
+
+// metavariable returns the name of the metavariable a pattern node is, if any.
+// Only a simple variable or a bare variable name can be a metavariable.
+func (m *matcher) metavariable(pattern phrase.AstNode) string {
+	switch p := pattern.(type) {
+	case *lexer.Token:
+		if p.Type != lexer.VariableName {
+			return ""
+		}
+	case *phrase.Phrase:
+		if p.Type != phrase.SimpleVariable {
+			return ""
+		}
+	}
+	if name := m.variable(pattern); metavariable.MatchString(name) {
+		return name
+	}
+	return ""
+}
+
+func (m *matcher) bind(name string, nodes []phrase.AstNode, b bindings) (bindings, bool) {
+	bound, ok := b[name]
+	if !ok {
+		return b.with(name, nodes), true
+	}
+	if len(bound) != len(nodes) {
+		return nil, false
+	}
+	for i := range bound {
+		if !m.equal(bound[i], nodes[i]) {
+			return nil, false
+		}
+	}
+	return b, true
+}
+
+// sequence matches pattern nodes against a sequence of nodes, returning how
+// many nodes were matched. Unless prefix is set, all of them must be.
+func (m *matcher) sequence(pattern, nodes []phrase.AstNode, b bindings, prefix bool) (bindings, int, bool) {
+	if len(pattern) == 0 {
+		return b, 0, prefix || len(nodes) == 0
+	}
+	name := m.variadic(pattern[0])
+	if name != "" || m.variable(pattern[0]) == ellipsis {
+		for n := 0; n <= len(nodes); n++ {
+			next := b
+			if name != "" {
+				var ok bool
+				if next, ok = m.bind(name, nodes[:n], b); !ok {
+					continue
+				}
+			}
+			if next, rest, ok := m.sequence(pattern[1:], nodes[n:], next, prefix); ok {
+				return next, n + rest, true
+			}
+		}
+		return nil, 0, false
+	}
+	if len(nodes) == 0 {
+		return nil, 0, false
+	}
+	next, ok := m.node(pattern[0], nodes[0], b)
+	if !ok {
+		return nil, 0, false
+	}
+	next, rest, ok := m.sequence(pattern[1:], nodes[1:], next, prefix)
+	return next, rest + 1, ok
+}
+
+// equal reports whether two nodes of the source are the same code
+func (m *matcher) equal(a, b phrase.AstNode) bool {
+	switch a := a.(type) {
+	case *lexer.Token:
+		t, ok := b.(*lexer.Token)
+		return ok && t.Type == a.Type &&
+			sameText(a.Type, m.source[a.Offset:a.Offset+a.Length], m.source[t.Offset:t.Offset+t.Length])
+	case *phrase.Phrase:
+		p, ok := b.(*phrase.Phrase)
+		if !ok || p.Type != a.Type {
+			return false
+		}
+		x, y := significant(a), significant(p)
+		if len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !m.equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// sameText compares names and keywords case insensitively, as PHP does
+func sameText(tokenType lexer.TokenType, a, b []byte) bool {
+	if tokenType == lexer.Name || tokenType >= lexer.Abstract && tokenType <= lexer.TraitConstant {
+		return bytes.EqualFold(a, b)
+	}
+	return bytes.Equal(a, b)
+}
@@ -0,0 +1,93 @@
+package query
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+)
+
+const source = `<?php
+$method->setAccessible(true);
+$this->property->setAccessible( /* why */ TRUE );
+$method->setAccessible(false);
+$a = new Foo(1, 2);
+$b = new \Bar\Baz();
+$c = new Qux;
+foo($x, $x);
+foo($x, $y);
+foo(1, 2, 3);
+foo();
+function f() {
+    open();
+    read();
+    close();
+}
+function g() {
+    open();
+    close();
+}
+`
+
+// matches returns the text of each match and its bindings, sorted by name
+func matches(t *testing.T, pattern string) []string {
+	p, err := Compile(pattern)
+	if err != nil {
+		t.Fatalf("%s: %v", pattern, err)
+	}
+	result := []string{}
+	for _, m := range p.Match(parser.Parse([]byte(source)), []byte(source)) {
+		text := source[m.Start:m.End]
+		names := []string{}
+		for name := range m.Bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			text += " " + name + "=" + m.Bindings[name].Text([]byte(source))
+		}
+		result = append(result, text)
+	}
+	return result
+}
+
+func TestMatch(t *testing.T) {
+	for _, c := range []struct {
+		pattern  string
+		expected []string
+	}{
+		{"$X->setAccessible(true)", []string{
+			"$method->setAccessible(true) $X=$method",
+			"$this->property->setAccessible( /* why */ TRUE ) $X=$this->property",
+		}},
+		{"new $CLASS(...$ARGS)", []string{
+			"new Foo(1, 2) $ARGS=1, 2 $CLASS=Foo",
+			`new \Bar\Baz() $ARGS= $CLASS=\Bar\Baz`,
+		}},
+		{"foo($X, $X)", []string{"foo($x, $x) $X=$x"}},
+		{"foo(...)", []string{"foo($x, $x)", "foo($x, $y)", "foo(1, 2, 3)", "foo()"}},
+		{"foo($A, ...)", []string{"foo($x, $x) $A=$x", "foo($x, $y) $A=$x", "foo(1, 2, 3) $A=1"}},
+		{"foo(..., 3)", []string{"foo(1, 2, 3)"}},
+		{"open(); ... close();", []string{
+			"open();\n    read();\n    close();",
+			"open();\n    close();",
+		}},
+		{"open(); close();", []string{"open();\n    close();"}},
+		{"function f() { ... read(); ... }", []string{
+			"function f() {\n    open();\n    read();\n    close();\n}",
+		}},
+		{"$V = new Qux", []string{"$c = new Qux $V=$c"}},
+	} {
+		actual := matches(t, c.pattern)
+		if strings.Join(actual, "\n--\n") != strings.Join(c.expected, "\n--\n") {
+			t.Errorf("%s: expected %q, got %q", c.pattern, c.expected, actual)
+		}
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	if _, err := Compile("foo(("); err != ErrInvalidPattern {
+		t.Errorf("expected ErrInvalidPattern, got %v", err)
+	}
+}
@@ -0,0 +1,551 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+// Selector is a compiled query in the S-expression syntax of tree-sitter:
+//
+//	(MethodCallExpression (MemberName (Name) @method) (ArgumentExpressionList) @args)
+//
+// A node pattern is a phrase or token type name in parentheses followed by
+// patterns for its children, which must match children of the node in order
+// though not necessarily adjacent ones. Hidden tokens are never children.
+// Besides node patterns there are:
+//
+//	_ or (_)          any phrase or token
+//	"->"              a token with this text
+//	[(A) (B)]         either pattern
+//	pattern* + ?      zero or more, one or more, an optional child
+//	pattern @name     captures the node
+//	(#eq? @a @b)      the text of the captures is equal, or of a capture and a string
+//	(#match? @a "re") the text of the capture matches a regular expression
+//
+// #not-eq? and #not-match? negate the predicates. A query may hold several top
+// level patterns; ; starts a comment.
+type Selector struct {
+	patterns []*selectorPattern
+}
+
+// Capture is a node captured by a selector, Start and End being its byte
+// offsets
+type Capture struct {
+	Name  string
+	Node  phrase.AstNode
+	Start int
+	End   int
+}
+
+// SelectorMatch is a match of the top level pattern of index Pattern
+type SelectorMatch struct {
+	Pattern  int
+	Captures []Capture
+}
+
+type selectorPattern struct {
+	root       *selectorNode
+	predicates []predicate
+}
+
+type selectorNode struct {
+	// isToken tells whether the type is tokenType rather than phraseType
+	isToken    bool
+	phraseType phrase.PhraseType
+	tokenType  lexer.TokenType
+	wildcard   bool
+	literal    string
+	// alternatives is set for [...]
+	alternatives []*selectorNode
+	children     []*selectorNode
+	quantifier   byte
+	captures     []string
+}
+
+type predicate struct {
+	name string
+	// args are capture names, prefixed with @, or strings
+	args []string
+	re   *regexp.Regexp
+}
+
+// CompileSelector parses a query in the S-expression syntax
+func CompileSelector(query string) (*Selector, error) {
+	p := &selectorParser{source: query}
+	s := &Selector{}
+	for p.skipSpace(); p.offset < len(p.source); p.skipSpace() {
+		pattern := &selectorPattern{}
+		root, err := p.node(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if root == nil {
+			if len(s.patterns) == 0 {
+				return nil, p.errorf("expected a pattern before a predicate")
+			}
+			// a top level predicate applies to the pattern before it
+			last := s.patterns[len(s.patterns)-1]
+			last.predicates = append(last.predicates, pattern.predicates...)
+			continue
+		}
+		pattern.root = root
+		s.patterns = append(s.patterns, pattern)
+	}
+	if len(s.patterns) == 0 {
+		return nil, p.errorf("empty query")
+	}
+	for _, pattern := range s.patterns {
+		for _, pred := range pattern.predicates {
+			for _, arg := range pred.args {
+				if strings.HasPrefix(arg, "@") && !pattern.root.hasCapture(arg[1:]) {
+					return nil, fmt.Errorf("query: predicate %s refers to unknown capture %s", pred.name, arg)
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+func (n *selectorNode) hasCapture(name string) bool {
+	for _, c := range n.captures {
+		if c == name {
+			return true
+		}
+	}
+	for _, children := range [][]*selectorNode{n.children, n.alternatives} {
+		for _, child := range children {
+			if child.hasCapture(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type selectorParser struct {
+	source string
+	offset int
+}
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("query: %s at offset %d", fmt.Sprintf(format, args...), p.offset)
+}
+
+func (p *selectorParser) skipSpace() {
+	for p.offset < len(p.source) {
+		switch c := p.source[p.offset]; {
+		case c == ';':
+			for p.offset < len(p.source) && p.source[p.offset] != '\n' {
+				p.offset++
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.offset++
+		default:
+			return
+		}
+	}
+}
+
+func (p *selectorParser) identifier() string {
+	start := p.offset
+	for p.offset < len(p.source) {
+		c := p.source[p.offset]
+		if c != '_' && c != '-' && c != '?' && c != '.' &&
+			(c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			break
+		}
+		p.offset++
+	}
+	return p.source[start:p.offset]
+}
+
+func (p *selectorParser) str() (string, error) {
+	var b strings.Builder
+	for p.offset++; p.offset < len(p.source); p.offset++ {
+		switch c := p.source[p.offset]; c {
+		case '"':
+			p.offset++
+			return b.String(), nil
+		case '\\':
+			p.offset++
+			if p.offset == len(p.source) {
+				break
+			}
+			switch c := p.source[p.offset]; c {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// node parses a pattern with its quantifier and captures. It returns nil for a
+// predicate, which is added to the enclosing top level pattern.
+func (p *selectorParser) node(pattern *selectorPattern) (*selectorNode, error) {
+	p.skipSpace()
+	if p.offset == len(p.source) {
+		return nil, p.errorf("unexpected end of query")
+	}
+	n := &selectorNode{}
+	switch c := p.source[p.offset]; {
+	case c == '(':
+		p.offset++
+		p.skipSpace()
+		if strings.HasPrefix(p.source[p.offset:], "#") {
+			p.offset++
+			return nil, p.predicate(pattern)
+		}
+		if err := p.nodeType(n); err != nil {
+			return nil, err
+		}
+		for {
+			p.skipSpace()
+			if p.offset == len(p.source) {
+				return nil, p.errorf("missing )")
+			}
+			if p.source[p.offset] == ')' {
+				p.offset++
+				break
+			}
+			child, err := p.node(pattern)
+			if err != nil {
+				return nil, err
+			}
+			if child != nil {
+				n.children = append(n.children, child)
+			}
+		}
+	case c == '[':
+		p.offset++
+		for {
+			p.skipSpace()
+			if p.offset == len(p.source) {
+				return nil, p.errorf("missing ]")
+			}
+			if p.source[p.offset] == ']' {
+				p.offset++
+				break
+			}
+			alternative, err := p.node(pattern)
+			if err != nil {
+				return nil, err
+			}
+			if alternative == nil {
+				return nil, p.errorf("unexpected predicate in alternatives")
+			}
+			n.alternatives = append(n.alternatives, alternative)
+		}
+		if len(n.alternatives) == 0 {
+			return nil, p.errorf("empty alternatives")
+		}
+	case c == '"':
+		literal, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		if literal == "" {
+			return nil, p.errorf("empty string")
+		}
+		n.literal = literal
+	case c == '_':
+		p.offset++
+		n.wildcard = true
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+
+	p.skipSpace()
+	if p.offset < len(p.source) {
+		switch c := p.source[p.offset]; c {
+		case '*', '+', '?':
+			n.quantifier = c
+			p.offset++
+		}
+	}
+	for p.skipSpace(); strings.HasPrefix(p.source[p.offset:], "@"); p.skipSpace() {
+		p.offset++
+		name := p.identifier()
+		if name == "" {
+			return nil, p.errorf("missing capture name")
+		}
+		n.captures = append(n.captures, name)
+	}
+	return n, nil
+}
+
+func (p *selectorParser) nodeType(n *selectorNode) error {
+	name := p.identifier()
+	if name == "_" {
+		n.wildcard = true
+		return nil
+	}
+	if t, ok := phrase.PhraseTypeByName(name); ok {
+		n.phraseType = t
+		return nil
+	}
+	if t, ok := lexer.TokenTypeByName(name); ok {
+		n.isToken = true
+		n.tokenType = t
+		return nil
+	}
+	if name == "" {
+		return p.errorf("missing node type")
+	}
+	return p.errorf("unknown node type %s", name)
+}
+
+func (p *selectorParser) predicate(pattern *selectorPattern) error {
+	pred := predicate{name: p.identifier()}
+	for {
+		p.skipSpace()
+		if p.offset == len(p.source) {
+			return p.errorf("missing )")
+		}
+		c := p.source[p.offset]
+		if c == ')' {
+			p.offset++
+			break
+		}
+		switch c {
+		case '@':
+			p.offset++
+			pred.args = append(pred.args, "@"+p.identifier())
+		case '"':
+			s, err := p.str()
+			if err != nil {
+				return err
+			}
+			pred.args = append(pred.args, s)
+		default:
+			return p.errorf("unexpected %q in predicate", c)
+		}
+	}
+
+	if len(pred.args) != 2 || !strings.HasPrefix(pred.args[0], "@") {
+		return p.errorf("#%s expects a capture and a capture or string", pred.name)
+	}
+	switch pred.name {
+	case "eq?", "not-eq?":
+	case "match?", "not-match?":
+		if strings.HasPrefix(pred.args[1], "@") {
+			return p.errorf("#%s expects a regular expression", pred.name)
+		}
+		re, err := regexp.Compile(pred.args[1])
+		if err != nil {
+			return fmt.Errorf("query: %v", err)
+		}
+		pred.re = re
+	default:
+		return p.errorf("unknown predicate #%s", pred.name)
+	}
+	pattern.predicates = append(pattern.predicates, pred)
+	return nil
+}
+
+// Match returns the matches of the selector in the tree of source, in the
+// order of the nodes they match and of the patterns
+func (s *Selector) Match(root *phrase.Phrase, source []byte) []SelectorMatch {
+	m := &selectorMatcher{source: source}
+	var result []SelectorMatch
+	var visit func(node phrase.AstNode)
+	visit = func(node phrase.AstNode) {
+		if t, ok := node.(*lexer.Token); ok && (t.Type == lexer.Comment || t.Type == lexer.Whitespace) {
+			return
+		}
+		for i, pattern := range s.patterns {
+			if !m.typeMatches(pattern.root, node) {
+				continue
+			}
+			captures, ok := m.node(pattern.root, node, nil)
+			if ok && m.predicates(pattern.predicates, captures) {
+				result = append(result, SelectorMatch{i, captures})
+			}
+		}
+		switch node := node.(type) {
+		case *phrase.Phrase:
+			for _, child := range node.Children {
+				visit(child)
+			}
+		case *phrase.ParseError:
+			for _, child := range node.Children {
+				visit(child)
+			}
+		}
+	}
+	visit(root)
+	return result
+}
+
+// selectorChildren returns the children of a phrase but its hidden tokens
+func selectorChildren(node phrase.AstNode) []phrase.AstNode {
+	var children []phrase.AstNode
+	switch node := node.(type) {
+	case *phrase.Phrase:
+		children = node.Children
+	case *phrase.ParseError:
+		children = node.Children
+	}
+	var result []phrase.AstNode
+	for _, child := range children {
+		if t, ok := child.(*lexer.Token); ok && (t.Type == lexer.Comment || t.Type == lexer.Whitespace) {
+			continue
+		}
+		result = append(result, child)
+	}
+	return result
+}
+
+type selectorMatcher struct {
+	source []byte
+}
+
+func (m *selectorMatcher) text(node phrase.AstNode) string {
+	first, last := phrase.FirstToken(node), phrase.LastToken(node)
+	if first == nil {
+		return ""
+	}
+	return string(m.source[first.Offset : last.Offset+last.Length])
+}
+
+// typeMatches checks the type of a node without looking at its children
+func (m *selectorMatcher) typeMatches(n *selectorNode, node phrase.AstNode) bool {
+	switch {
+	case n.alternatives != nil:
+		for _, alternative := range n.alternatives {
+			if m.typeMatches(alternative, node) {
+				return true
+			}
+		}
+		return false
+	case n.wildcard:
+		return true
+	case n.literal != "":
+		t, ok := node.(*lexer.Token)
+		return ok && string(m.source[t.Offset:t.Offset+t.Length]) == n.literal
+	case n.isToken:
+		t, ok := node.(*lexer.Token)
+		return ok && t.Type == n.tokenType
+	}
+	switch p := node.(type) {
+	case *phrase.Phrase:
+		return p.Type == n.phraseType
+	case *phrase.ParseError:
+		return p.Type == n.phraseType
+	}
+	return false
+}
+
+func (m *selectorMatcher) node(n *selectorNode, node phrase.AstNode, captures []Capture) ([]Capture, bool) {
+	if n.alternatives != nil {
+		for _, alternative := range n.alternatives {
+			if result, ok := m.node(alternative, node, captures); ok {
+				return m.capture(n, node, result), true
+			}
+		}
+		return nil, false
+	}
+	if !m.typeMatches(n, node) {
+		return nil, false
+	}
+	result, ok := m.children(n.children, selectorChildren(node), captures)
+	if !ok {
+		return nil, false
+	}
+	return m.capture(n, node, result), true
+}
+
+func (m *selectorMatcher) capture(n *selectorNode, node phrase.AstNode, captures []Capture) []Capture {
+	for _, name := range n.captures {
+		c := Capture{Name: name, Node: node}
+		if first, last := phrase.FirstToken(node), phrase.LastToken(node); first != nil {
+			c.Start, c.End = first.Offset, last.Offset+last.Length
+		}
+		// copy so that backtracking does not overwrite captures of other branches
+		captures = append(captures[:len(captures):len(captures)], c)
+	}
+	return captures
+}
+
+// children matches child patterns in order against a subsequence of nodes
+func (m *selectorMatcher) children(patterns []*selectorNode, nodes []phrase.AstNode,
+	captures []Capture) ([]Capture, bool) {
+	if len(patterns) == 0 {
+		return captures, true
+	}
+	n := patterns[0]
+	for i, node := range nodes {
+		result, ok := m.node(n, node, captures)
+		if !ok {
+			continue
+		}
+		rest := patterns[1:]
+		if n.quantifier == '*' || n.quantifier == '+' {
+			// try to match this pattern again, as zero or more
+			again := *n
+			again.quantifier = '*'
+			rest = append([]*selectorNode{&again}, rest...)
+		}
+		if result, ok := m.children(rest, nodes[i+1:], result); ok {
+			return result, true
+		}
+	}
+	if n.quantifier == '*' || n.quantifier == '?' {
+		// quantifiers are greedy, matching without this pattern is the last resort
+		return m.children(patterns[1:], nodes, captures)
+	}
+	return nil, false
+}
+
+func (m *selectorMatcher) predicates(predicates []predicate, captures []Capture) bool {
+	for _, pred := range predicates {
+		texts := m.captured(pred.args[0][1:], captures)
+		var ok bool
+		switch pred.name {
+		case "eq?", "not-eq?":
+			expected := []string{pred.args[1]}
+			if strings.HasPrefix(pred.args[1], "@") {
+				expected = m.captured(pred.args[1][1:], captures)
+			}
+			// a string is compared with every node of a quantified capture, an
+			// empty capture matches nothing
+			ok = len(texts) > 0 && len(expected) > 0 &&
+				(len(expected) == 1 || len(texts) == len(expected))
+			for i := 0; ok && i < len(texts); i++ {
+				ok = texts[i] == expected[i%len(expected)]
+			}
+		case "match?", "not-match?":
+			ok = len(texts) > 0
+			for _, text := range texts {
+				ok = ok && pred.re.MatchString(text)
+			}
+		}
+		if strings.HasPrefix(pred.name, "not-") {
+			ok = !ok
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *selectorMatcher) captured(name string, captures []Capture) []string {
+	var result []string
+	for _, c := range captures {
+		if c.Name == name {
+			result = append(result, m.text(c.Node))
+		}
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package query
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+const selectorSource = `<?php
+$a->foo(1);
+$b->bar();
+$a->baz($a, $b, $c);
+$x = $x;
+$x = $y;
+`
+
+// captures returns the captures of each match as name=text, separated by
+// spaces
+func captures(t *testing.T, query string) []string {
+	s, err := CompileSelector(query)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	result := []string{}
+	for _, m := range s.Match(parser.Parse([]byte(selectorSource)), []byte(selectorSource)) {
+		texts := []string{}
+		for _, c := range m.Captures {
+			texts = append(texts, c.Name+"="+selectorSource[c.Start:c.End])
+		}
+		result = append(result, strings.Join(texts, " "))
+	}
+	return result
+}
+
+func TestSelector(t *testing.T) {
+	for _, c := range []struct {
+		query    string
+		expected []string
+	}{
+		{"(MethodCallExpression (MemberName (Name) @method) (ArgumentExpressionList) @args)", []string{
+			"method=foo args=(1)",
+			"method=bar args=()",
+			"method=baz args=($a, $b, $c)",
+		}},
+		{`(MethodCallExpression (SimpleVariable) @object (MemberName) @method (#eq? @object "$a"))`, []string{
+			"object=$a method=foo",
+			"object=$a method=baz",
+		}},
+		{`(MethodCallExpression (MemberName) @method (#match? @method "^ba"))`, []string{
+			"method=bar",
+			"method=baz",
+		}},
+		{`(MethodCallExpression (MemberName) @method (#not-match? @method "^ba"))`, []string{"method=foo"}},
+		{"(ArgumentExpressionList (SimpleVariable)+ @arg)", []string{"arg=$a arg=$b arg=$c"}},
+		{`(ArgumentExpressionList "(" (IntegerLiteral)? @int ")") @list`, []string{
+			"int=1 list=(1)",
+			"list=()",
+			"list=($a, $b, $c)",
+		}},
+		{`(ArgumentExpressionList "(" (IntegerLiteral)? @int ")") @list (#eq? @list @int)`, []string{}},
+		{`(ArgumentExpressionList "(" (IntegerLiteral)? @int ")") @list (#not-eq? @list @int)`, []string{
+			"int=1 list=(1)",
+			"list=()",
+			"list=($a, $b, $c)",
+		}},
+		{"(SimpleAssignmentExpression (SimpleVariable) @left (SimpleVariable) @right) (#eq? @left @right)", []string{
+			"left=$x right=$x",
+		}},
+		{"(SimpleAssignmentExpression (SimpleVariable) @left (SimpleVariable) @right (#not-eq? @left @right))", []string{
+			"left=$x right=$y",
+		}},
+		{"[(IntegerLiteral) (MemberName)] @node", []string{
+			"node=foo", "node=1", "node=bar", "node=baz",
+		}},
+		{"(MemberName (_) @name) ; any child", []string{"name=foo", "name=bar", "name=baz"}},
+		{"(ExpressionStatement _ (Semicolon) @end) (SimpleAssignmentExpression)", []string{
+			"end=;", "end=;", "end=;", "end=;", "", "end=;", "",
+		}},
+	} {
+		actual := captures(t, c.query)
+		if strings.Join(actual, "\n") != strings.Join(c.expected, "\n") {
+			t.Errorf("%s: expected %q, got %q", c.query, c.expected, actual)
+		}
+	}
+}
+
+func TestSelectorErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"(Unknown",
+		"(NotAType)",
+		"(Name) @",
+		`(Name) @a (#eq? @b "x")`,
+		`(Name) @a (#match? @a "(")`,
+		`(Name) @a (#what? @a "x")`,
+		"[]",
+		`(Name "")`,
+	} {
+		if _, err := CompileSelector(query); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
+
+func BenchmarkSelector(b *testing.B) {
+	s, err := CompileSelector(`(MethodCallExpression (MemberName) @method (ArgumentExpressionList) @args)
+(FunctionCallExpression (QualifiedName) @function (#match? @function "^(sprintf|count)$"))`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	paths, _ := filepath.Glob("../cases/*.php")
+	var roots []*phrase.Phrase
+	var sources [][]byte
+	for _, path := range paths {
+		source, err := ioutil.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		roots = append(roots, parser.Parse(source))
+		sources = append(sources, source)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, root := range roots {
+			s.Match(root, sources[j])
+		}
+	}
+}
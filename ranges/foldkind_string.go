@@ -0,0 +1,26 @@
+// Code generated by "stringer -type=FoldKind"; DO NOT EDIT.
+
+package ranges
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Block-0]
+	_ = x[Comment-1]
+	_ = x[Imports-2]
+	_ = x[Region-3]
+}
+
+const _FoldKind_name = "BlockCommentImportsRegion"
+
+var _FoldKind_index = [...]uint8{0, 5, 12, 19, 25}
+
+func (i FoldKind) String() string {
+	if i >= FoldKind(len(_FoldKind_index)-1) {
+		return "FoldKind(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _FoldKind_name[_FoldKind_index[i]:_FoldKind_index[i+1]]
+}
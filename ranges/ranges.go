@@ -0,0 +1,213 @@
+// Package ranges computes folding ranges and selection ranges from the
+// phrase tree.
+package ranges
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+	"github.com/john-nguyen09/go-phpparser/position"
+)
+
+type FoldKind uint8
+
+const (
+	Block FoldKind = iota
+	Comment
+	Imports
+	Region
+)
+
+//go:generate stringer -type=FoldKind
+
+// Fold is a range of zero based lines, both inclusive, that can be collapsed
+type Fold struct {
+	StartLine int
+	EndLine   int
+	Kind      FoldKind
+}
+
+var (
+	regionStart = regexp.MustCompile(`^(#|//)\s*region\b`)
+	regionEnd   = regexp.MustCompile(`^(#|//)\s*endregion\b`)
+)
+
+type folder struct {
+	source  []byte
+	index   *position.Index
+	folds   []Fold
+	regions []int
+}
+
+// Folding returns the folds of declaration bodies, blocks, arrays, multi-line
+// argument lists, comments, heredocs, runs of use declarations and
+// #region/#endregion comments, ordered by start line. A fold ends on the line
+// before a closing bracket that starts its line so that the bracket stays
+// visible, and only one fold starts on a given line, the outermost.
+func Folding(root *phrase.Phrase, source []byte, index *position.Index) []Fold {
+	f := &folder{source: source, index: index}
+	f.visit(root)
+
+	sort.SliceStable(f.folds, func(i, j int) bool {
+		return f.folds[i].StartLine < f.folds[j].StartLine
+	})
+	result := []Fold{}
+	for _, fold := range f.folds {
+		if len(result) > 0 && result[len(result)-1].StartLine == fold.StartLine {
+			continue
+		}
+		result = append(result, fold)
+	}
+	return result
+}
+
+func (f *folder) visit(node phrase.AstNode) {
+	switch node := node.(type) {
+	case *lexer.Token:
+		if node.Type == lexer.Comment {
+			f.comment(node)
+		}
+	case *phrase.Phrase:
+		f.phrase(node)
+		f.visitChildren(node.Children)
+	case *phrase.ParseError:
+		f.visitChildren(node.Children)
+	}
+}
+
+func (f *folder) visitChildren(children []phrase.AstNode) {
+	var uses []phrase.AstNode
+	for _, child := range children {
+		if p, ok := child.(*phrase.Phrase); ok && p.Type == phrase.NamespaceUseDeclaration {
+			uses = append(uses, p)
+		} else if t, ok := child.(*lexer.Token); !ok || (t.Type != lexer.Comment && t.Type != lexer.Whitespace) {
+			f.imports(uses)
+			uses = nil
+		}
+		f.visit(child)
+	}
+	f.imports(uses)
+}
+
+func (f *folder) phrase(p *phrase.Phrase) {
+	switch p.Type {
+	case phrase.ClassDeclarationBody,
+		phrase.InterfaceDeclarationBody,
+		phrase.TraitDeclarationBody,
+		phrase.FunctionDeclarationBody,
+		phrase.MethodDeclarationBody,
+		phrase.CompoundStatement,
+		phrase.ArrayCreationExpression,
+		phrase.ArgumentExpressionList,
+		phrase.NamespaceUseGroupClauseList,
+		phrase.HeredocStringLiteral:
+		f.add(phrase.FirstToken(p), phrase.LastToken(p), Block)
+	case phrase.DocumentComment:
+		f.add(phrase.FirstToken(p), phrase.LastToken(p), Comment)
+	}
+}
+
+// imports folds a run of use declarations
+func (f *folder) imports(uses []phrase.AstNode) {
+	if len(uses) > 0 {
+		f.add(phrase.FirstToken(uses[0]), phrase.LastToken(uses[len(uses)-1]), Imports)
+	}
+}
+
+func (f *folder) comment(t *lexer.Token) {
+	text := f.source[t.Offset : t.Offset+t.Length]
+	switch {
+	case regionStart.Match(text):
+		f.regions = append(f.regions, f.index.Line(t.Offset))
+	case regionEnd.Match(text):
+		if len(f.regions) == 0 {
+			return
+		}
+		start := f.regions[len(f.regions)-1]
+		f.regions = f.regions[:len(f.regions)-1]
+		if end := f.index.Line(t.Offset); end > start {
+			f.folds = append(f.folds, Fold{start, end, Region})
+		}
+	default:
+		f.add(t, t, Comment)
+	}
+}
+
+func (f *folder) add(first, last *lexer.Token, kind FoldKind) {
+	if first == nil {
+		return
+	}
+	startLine := f.index.Line(first.Offset)
+	// the last line is that of the final character; a heredoc's end token
+	// starts with the line break before its label
+	endLine := f.index.Line(last.Offset + last.Length - 1)
+	switch last.Type {
+	case lexer.EndHeredoc:
+		endLine--
+	case lexer.CloseBrace, lexer.CloseBracket, lexer.CloseParenthesis:
+		if f.startsLine(last) {
+			endLine--
+		}
+	}
+	if endLine > startLine {
+		f.folds = append(f.folds, Fold{startLine, endLine, kind})
+	}
+}
+
+// startsLine reports whether only whitespace precedes t on its line
+func (f *folder) startsLine(t *lexer.Token) bool {
+	lineStart := f.index.LineStart(f.index.Line(t.Offset))
+	return len(bytes.TrimSpace(f.source[lineStart:t.Offset])) == 0
+}
+
+// Span is a range of byte offsets, End being exclusive
+type Span struct {
+	Start int
+	End   int
+}
+
+// Selection returns the spans enclosing offset from the innermost, the token at
+// offset, through each enclosing phrase up to the root. Phrases with the same
+// extent as the previous span are skipped.
+func Selection(root *phrase.Phrase, offset int) []Span {
+	var path []phrase.AstNode
+	var node phrase.AstNode = root
+	for node != nil {
+		path = append(path, node)
+		var children []phrase.AstNode
+		switch p := node.(type) {
+		case *phrase.Phrase:
+			children = p.Children
+		case *phrase.ParseError:
+			children = p.Children
+		}
+		node = nil
+		for _, child := range children {
+			first, last := phrase.FirstToken(child), phrase.LastToken(child)
+			if first != nil && first.Offset <= offset && offset <= last.Offset+last.Length {
+				node = child
+				// at the boundary of two tokens, prefer the one starting at offset
+				if t, ok := child.(*lexer.Token); !ok || offset < t.Offset+t.Length {
+					break
+				}
+			}
+		}
+	}
+
+	result := []Span{}
+	for i := len(path) - 1; i >= 0; i-- {
+		first, last := phrase.FirstToken(path[i]), phrase.LastToken(path[i])
+		if first == nil {
+			continue
+		}
+		span := Span{first.Offset, last.Offset + last.Length}
+		if len(result) > 0 && result[len(result)-1] == span {
+			continue
+		}
+		result = append(result, span)
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package ranges
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/position"
+)
+
+const source = `<?php
+use A\B;
+// imported
+use A\C;
+
+#region helpers
+/**
+ * Helper
+ */
+function helper($a)
+{
+    if ($a) {
+        return foo(
+            1,
+            [2, 3]
+        );
+    }
+    $s = <<<EOT
+heredoc
+text
+EOT;
+    foo(1,
+        2);
+}
+#endregion
+
+class Foo {
+    public function bar() {
+        return [
+            1,
+        ];
+    }
+}
+/* multi
+   line */
+`
+
+func TestFolding(t *testing.T) {
+	src := []byte(source)
+	folds := Folding(parser.Parse(src), src, position.NewIndex(src))
+	expected := []Fold{
+		{1, 3, Imports},
+		{5, 24, Region},
+		{6, 8, Comment},
+		{10, 22, Block}, // function body
+		{11, 15, Block}, // if block
+		{12, 14, Block}, // argument list, the array being on one line
+		{17, 19, Block}, // heredoc, its label staying visible
+		{21, 22, Block}, // argument list whose closing parenthesis follows an argument
+		{26, 31, Block}, // class body
+		{27, 30, Block}, // method body
+		{28, 29, Block}, // array
+		{33, 34, Comment},
+	}
+	if !reflect.DeepEqual(folds, expected) {
+		t.Errorf("expected %v, got %v", expected, folds)
+	}
+}
+
+func TestSelection(t *testing.T) {
+	src := "<?php\n$a = foo($b + 1);\n"
+	root := parser.Parse([]byte(src))
+	spans := Selection(root, strings.Index(src, "$b")+1)
+	texts := []string{}
+	for _, span := range spans {
+		texts = append(texts, src[span.Start:span.End])
+	}
+	expected := []string{
+		"$b",
+		"$b + 1",
+		"($b + 1)",
+		"foo($b + 1)",
+		"$a = foo($b + 1)",
+		"$a = foo($b + 1);",
+		"<?php\n$a = foo($b + 1);",
+	}
+	if !reflect.DeepEqual(texts, expected) {
+		t.Errorf("expected %q, got %q", expected, texts)
+	}
+
+	// between two tokens, the one starting at the offset is selected
+	spans = Selection(root, strings.Index(src, " +"))
+	if len(spans) == 0 || src[spans[0].Start:spans[0].End] != "$b" {
+		t.Errorf("expected $b to be selected before the space, got %v", spans)
+	}
+}
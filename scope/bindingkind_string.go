@@ -0,0 +1,36 @@
+// Code generated by "stringer -type=BindingKind"; DO NOT EDIT.
+
+package scope
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Assignment-0]
+	_ = x[Parameter-1]
+	_ = x[Global-2]
+	_ = x[Static-3]
+	_ = x[ClosureUse-4]
+	_ = x[ArrowCapture-5]
+	_ = x[ListDestructuring-6]
+	_ = x[ForeachKey-7]
+	_ = x[ForeachValue-8]
+	_ = x[Catch-9]
+	_ = x[This-10]
+	_ = x[Superglobal-11]
+	_ = x[Isset-12]
+	_ = x[Unset-13]
+}
+
+const _BindingKind_name = "AssignmentParameterGlobalStaticClosureUseArrowCaptureListDestructuringForeachKeyForeachValueCatchThisSuperglobalIssetUnset"
+
+var _BindingKind_index = [...]uint8{0, 10, 19, 25, 31, 41, 53, 70, 80, 92, 97, 101, 112, 117, 122}
+
+func (i BindingKind) String() string {
+	if i >= BindingKind(len(_BindingKind_index)-1) {
+		return "BindingKind(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _BindingKind_name[_BindingKind_index[i]:_BindingKind_index[i+1]]
+}
@@ -0,0 +1,27 @@
+// Code generated by "stringer -type=Kind"; DO NOT EDIT.
+
+package scope
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[File-0]
+	_ = x[Function-1]
+	_ = x[Method-2]
+	_ = x[Closure-3]
+	_ = x[ArrowFunction-4]
+}
+
+const _Kind_name = "FileFunctionMethodClosureArrowFunction"
+
+var _Kind_index = [...]uint8{0, 4, 12, 18, 25, 38}
+
+func (i Kind) String() string {
+	if i >= Kind(len(_Kind_index)-1) {
+		return "Kind(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+	return _Kind_name[_Kind_index[i]:_Kind_index[i+1]]
+}
@@ -0,0 +1,500 @@
+// Package scope resolves every variable in a parse tree to the binding it
+// refers to, per function, method, closure and arrow function.
+//
+// Resolution is flow-insensitive like PHP's own function scope: a variable
+// assigned anywhere in a function is bound everywhere in that function.
+package scope
+
+import (
+	"strings"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+type Kind uint8
+
+const (
+	File Kind = iota
+	Function
+	Method
+	Closure
+	ArrowFunction
+)
+
+//go:generate stringer -type=Kind
+
+type BindingKind uint8
+
+const (
+	Assignment BindingKind = iota
+	Parameter
+	Global
+	Static
+	ClosureUse
+	ArrowCapture
+	ListDestructuring
+	ForeachKey
+	ForeachValue
+	Catch
+	This
+	Superglobal
+	// Isset and Unset are variables first seen as an isset() or unset()
+	// argument, which PHP allows when they are undefined
+	Isset
+	Unset
+)
+
+//go:generate stringer -type=BindingKind
+
+// Scope is the variable scope of a file, function, method, closure or arrow
+// function
+type Scope struct {
+	Kind   Kind
+	Node   *phrase.Phrase
+	Parent *Scope
+	// Children are the scopes of functions, methods, closures and arrow
+	// functions declared directly in this scope
+	Children []*Scope
+	// Bindings in the order they were first introduced
+	Bindings []*Binding
+	// References are the reads of variables in this scope, resolved or not
+	References []*Reference
+	// Dynamic is set when the scope accesses variables by name, e.g. $$name,
+	// extract() or compact(), so unused and undefined checks are unreliable
+	Dynamic bool
+
+	static   bool
+	bindings map[string]*Binding
+	captures []capture
+	source   []byte
+}
+
+// capture is a closure use variable read from the enclosing scope
+type capture struct {
+	binding *Binding
+	ref     *Reference
+}
+
+// Binding is a variable introduced in a scope
+type Binding struct {
+	// Name including the leading $
+	Name string
+	// Kind tells how the variable was first introduced
+	Kind  BindingKind
+	Scope *Scope
+	// Declarations are the tokens that introduce or assign the variable. It
+	// is empty for $this and superglobals.
+	Declarations []*lexer.Token
+	References   []*Reference
+	// Captured is the binding in the enclosing scope that a closure use
+	// variable, arrow function capture or closure $this refers to
+	Captured *Binding
+}
+
+// Reference is a read of a variable
+type Reference struct {
+	Token *lexer.Token
+	Scope *Scope
+	// Binding is nil when the variable is undefined
+	Binding *Binding
+}
+
+// Analyse builds the scope tree for a tree returned by parser.Parse
+func Analyse(root *phrase.Phrase, source []byte) *Scope {
+	s := newScope(File, root, nil, source)
+	s.visitChildren(root)
+	s.resolve()
+	return s
+}
+
+// Lookup returns the binding of name, including the leading $, in this scope
+func (s *Scope) Lookup(name string) *Binding {
+	return s.bindings[name]
+}
+
+// Undefined returns the references in this scope that resolve to no binding
+func (s *Scope) Undefined() []*Reference {
+	var undefined []*Reference
+	for _, ref := range s.References {
+		if ref.Binding == nil {
+			undefined = append(undefined, ref)
+		}
+	}
+	return undefined
+}
+
+func newScope(kind Kind, node *phrase.Phrase, parent *Scope, source []byte) *Scope {
+	s := &Scope{
+		Kind:     kind,
+		Node:     node,
+		Parent:   parent,
+		bindings: map[string]*Binding{},
+		source:   source,
+	}
+	if parent != nil {
+		parent.Children = append(parent.Children, s)
+	}
+	return s
+}
+
+func (s *Scope) text(t *lexer.Token) string {
+	return string(s.source[t.Offset : t.Offset+t.Length])
+}
+
+func (s *Scope) binding(name string, kind BindingKind) *Binding {
+	b := s.bindings[name]
+	if b == nil {
+		b = &Binding{Name: name, Kind: kind, Scope: s}
+		s.bindings[name] = b
+		s.Bindings = append(s.Bindings, b)
+	}
+	return b
+}
+
+func (s *Scope) declare(t *lexer.Token, kind BindingKind) *Binding {
+	b := s.binding(s.text(t), kind)
+	b.Declarations = append(b.Declarations, t)
+	return b
+}
+
+func (s *Scope) reference(t *lexer.Token) {
+	s.References = append(s.References, &Reference{Token: t, Scope: s})
+}
+
+// resolve links references to bindings once every declaration of the tree is
+// known, parents before children so that captures see the complete parent
+func (s *Scope) resolve() {
+	for _, c := range s.captures {
+		c.binding.Captured = c.ref.Binding
+	}
+	for _, ref := range s.References {
+		ref.Binding = s.lookupFrom(s.text(ref.Token), ref.Token)
+		if ref.Binding != nil {
+			ref.Binding.References = append(ref.Binding.References, ref)
+		}
+	}
+	for _, child := range s.Children {
+		child.resolve()
+	}
+}
+
+// lookupFrom finds the binding of name, capturing it from the enclosing scope
+// where PHP does so implicitly
+func (s *Scope) lookupFrom(name string, t *lexer.Token) *Binding {
+	if isSuperglobal(name) {
+		root := s
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		return root.binding(name, Superglobal)
+	}
+	if b := s.bindings[name]; b != nil {
+		return b
+	}
+
+	if name == "$this" {
+		switch s.Kind {
+		case Method:
+			if s.static {
+				return nil
+			}
+			return s.binding(name, This)
+		case Closure, ArrowFunction:
+			if s.static || s.Parent == nil {
+				return nil
+			}
+			if captured := s.Parent.lookupFrom(name, t); captured != nil {
+				b := s.binding(name, This)
+				b.Captured = captured
+				return b
+			}
+		}
+		return nil
+	}
+
+	if s.Kind == ArrowFunction {
+		if captured := s.Parent.lookupFrom(name, t); captured != nil {
+			b := s.binding(name, ArrowCapture)
+			b.Captured = captured
+			ref := &Reference{Token: t, Scope: s.Parent, Binding: captured}
+			s.Parent.References = append(s.Parent.References, ref)
+			captured.References = append(captured.References, ref)
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *Scope) visit(node phrase.AstNode) {
+	p, ok := node.(*phrase.Phrase)
+	if !ok {
+		if err, ok := node.(*phrase.ParseError); ok {
+			s.visitChildren(&err.Phrase)
+		}
+		return
+	}
+
+	switch p.Type {
+	case phrase.FunctionDeclaration:
+		newScope(Function, p, s, s.source).visitChildren(p)
+	case phrase.MethodDeclaration:
+		child := newScope(Method, p, s, s.source)
+		child.static = hasStaticModifier(p)
+		child.visitChildren(p)
+	case phrase.AnonymousFunctionCreationExpression:
+		child := newScope(Closure, p, s, s.source)
+		child.static = hasStaticModifier(p)
+		child.visitChildren(p)
+	case phrase.ArrowFunctionCreationExpression:
+		child := newScope(ArrowFunction, p, s, s.source)
+		child.static = hasStaticModifier(p)
+		child.visitChildren(p)
+	case phrase.ParameterDeclaration:
+		for _, c := range p.Children {
+			if t, ok := c.(*lexer.Token); ok && t.Type == lexer.VariableName {
+				s.declare(t, Parameter)
+			} else {
+				s.visit(c)
+			}
+		}
+	case phrase.AnonymousFunctionUseVariable:
+		s.closureUse(p)
+	case phrase.SimpleAssignmentExpression, phrase.ByRefAssignmentExpression,
+		phrase.CompoundAssignmentExpression:
+		for i, c := range p.Children {
+			if i == 0 {
+				s.assign(c, Assignment)
+			} else {
+				s.visit(c)
+			}
+		}
+	case phrase.IssetIntrinsic, phrase.UnsetIntrinsic:
+		kind := Isset
+		if p.Type == phrase.UnsetIntrinsic {
+			kind = Unset
+		}
+		for _, c := range p.Children {
+			if list, ok := c.(*phrase.Phrase); ok && list.Type == phrase.VariableList {
+				s.visitAssigned(list, kind)
+			} else {
+				s.visit(c)
+			}
+		}
+	case phrase.ForeachKey:
+		s.visitAssigned(p, ForeachKey)
+	case phrase.ForeachValue:
+		s.visitAssigned(p, ForeachValue)
+	case phrase.VariableNameList: // global $a, $b
+		for _, c := range p.Children {
+			s.assign(c, Global)
+		}
+	case phrase.StaticVariableDeclaration:
+		for _, c := range p.Children {
+			if t, ok := c.(*lexer.Token); ok && t.Type == lexer.VariableName {
+				s.declare(t, Static)
+			} else {
+				s.visit(c)
+			}
+		}
+	case phrase.CatchClause:
+		for _, c := range p.Children {
+			if t, ok := c.(*lexer.Token); ok && t.Type == lexer.VariableName {
+				s.declare(t, Catch)
+			} else {
+				s.visit(c)
+			}
+		}
+	case phrase.FunctionCallExpression:
+		if isDynamicFunction(s.source, p) {
+			s.Dynamic = true
+		}
+		s.visitChildren(p)
+	case phrase.SimpleVariable:
+		if t := variableName(p); t != nil {
+			s.reference(t)
+			return
+		}
+		// $$name or ${expression}
+		s.Dynamic = true
+		s.visitChildren(p)
+	default:
+		s.visitChildren(p)
+	}
+}
+
+func (s *Scope) visitChildren(p *phrase.Phrase) {
+	for _, c := range p.Children {
+		s.visit(c)
+	}
+}
+
+// visitAssigned assigns every phrase child of p, e.g. the value of a foreach
+// which may be preceded by &
+func (s *Scope) visitAssigned(p *phrase.Phrase, kind BindingKind) {
+	for _, c := range p.Children {
+		s.assign(c, kind)
+	}
+}
+
+// assign declares the variables written by an assignment target: a plain
+// variable, list() or [] destructuring, or an array element such as $a[] which
+// creates $a when missing
+func (s *Scope) assign(node phrase.AstNode, kind BindingKind) {
+	p, ok := node.(*phrase.Phrase)
+	if !ok {
+		s.visit(node)
+		return
+	}
+
+	switch p.Type {
+	case phrase.SimpleVariable:
+		if t := variableName(p); t != nil {
+			s.declare(t, kind)
+			return
+		}
+	case phrase.ListIntrinsic, phrase.ArrayCreationExpression:
+		if kind == Assignment {
+			kind = ListDestructuring
+		}
+		for _, c := range p.Children {
+			if list, ok := c.(*phrase.Phrase); ok && list.Type == phrase.ArrayInitialiserList {
+				s.destructure(list, kind)
+			}
+		}
+		return
+	case phrase.SubscriptExpression:
+		for i, c := range p.Children {
+			if i == 0 {
+				s.assign(c, kind)
+			} else {
+				s.visit(c)
+			}
+		}
+		return
+	}
+	s.visit(p)
+}
+
+func (s *Scope) destructure(list *phrase.Phrase, kind BindingKind) {
+	for _, c := range list.Children {
+		element, ok := c.(*phrase.Phrase)
+		if !ok || element.Type != phrase.ArrayElement {
+			continue
+		}
+		for _, c := range element.Children {
+			if value, ok := c.(*phrase.Phrase); ok && value.Type == phrase.ArrayValue {
+				s.visitAssigned(value, kind)
+			} else {
+				s.visit(c)
+			}
+		}
+	}
+}
+
+// closureUse binds a use variable in the closure and reads it from the
+// enclosing scope, or declares it there when it is captured by reference
+func (s *Scope) closureUse(p *phrase.Phrase) {
+	var t *lexer.Token
+	byRef := false
+	for _, c := range p.Children {
+		if token, ok := c.(*lexer.Token); ok {
+			switch token.Type {
+			case lexer.Ampersand:
+				byRef = true
+			case lexer.VariableName:
+				t = token
+			}
+		}
+	}
+	if t == nil || s.Parent == nil {
+		return
+	}
+
+	b := s.declare(t, ClosureUse)
+	if byRef {
+		b.Captured = s.Parent.declare(t, ClosureUse)
+		return
+	}
+	ref := &Reference{Token: t, Scope: s.Parent}
+	s.Parent.References = append(s.Parent.References, ref)
+	s.captures = append(s.captures, capture{b, ref})
+}
+
+var superglobals = map[string]bool{
+	"$GLOBALS":  true,
+	"$_SERVER":  true,
+	"$_GET":     true,
+	"$_POST":    true,
+	"$_FILES":   true,
+	"$_COOKIE":  true,
+	"$_SESSION": true,
+	"$_REQUEST": true,
+	"$_ENV":     true,
+}
+
+func isSuperglobal(name string) bool {
+	return superglobals[name]
+}
+
+// dynamicFunctions read or write variables of the calling scope by name
+var dynamicFunctions = map[string]bool{
+	"compact":          true,
+	"extract":          true,
+	"get_defined_vars": true,
+	"parse_str":        true,
+}
+
+func isDynamicFunction(source []byte, p *phrase.Phrase) bool {
+	if len(p.Children) == 0 {
+		return false
+	}
+	name, ok := p.Children[0].(*phrase.Phrase)
+	if !ok || (name.Type != phrase.QualifiedName && name.Type != phrase.FullyQualifiedName) {
+		return false
+	}
+	last := phrase.LastToken(name)
+	return last != nil && last.Type == lexer.Name &&
+		dynamicFunctions[strings.ToLower(string(source[last.Offset:last.Offset+last.Length]))]
+}
+
+func variableName(p *phrase.Phrase) *lexer.Token {
+	if len(p.Children) == 0 {
+		return nil
+	}
+	if t, ok := p.Children[0].(*lexer.Token); ok && t.Type == lexer.VariableName {
+		return t
+	}
+	return nil
+}
+
+// hasStaticModifier checks a method's modifiers or the header of a static
+// closure or arrow function
+func hasStaticModifier(p *phrase.Phrase) bool {
+	for _, c := range p.Children {
+		header, ok := c.(*phrase.Phrase)
+		if !ok {
+			continue
+		}
+		switch header.Type {
+		case phrase.MethodDeclarationHeader:
+			for _, c := range header.Children {
+				if modifiers, ok := c.(*phrase.Phrase); ok && modifiers.Type == phrase.MemberModifierList {
+					return hasToken(modifiers, lexer.Static)
+				}
+			}
+		case phrase.AnonymousFunctionHeader, phrase.ArrowFunctionHeader:
+			return hasToken(header, lexer.Static)
+		}
+	}
+	return false
+}
+
+func hasToken(p *phrase.Phrase, tokenType lexer.TokenType) bool {
+	for _, c := range p.Children {
+		if t, ok := c.(*lexer.Token); ok && t.Type == tokenType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package scope
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/parser"
+)
+
+const source = `<?php
+$top = 1;
+function f(int $a, &$b, ...$c) {
+    global $g;
+    static $s = 1;
+    list($l1, list($l2)) = $a;
+    [$k1, 'k' => $k2] = $b;
+    $arr[] = $l1;
+    foreach ($c as $key => &$value) {}
+    try {} catch (A | B $e) {}
+    $fn = function ($p) use ($k1, &$byRef, $missing) { return $p + $k1 + $this; };
+    $af = fn($q) => fn() => $q + $key + $undefined;
+    echo $_GET['x'], $top;
+}
+class C {
+    public function m() { return fn() => $this; }
+    public static function s() { return $this; }
+}
+function dynamic() { extract([]); }
+`
+
+func analyse() *Scope {
+	return Analyse(parser.Parse([]byte(source)), []byte(source))
+}
+
+func bindings(s *Scope) string {
+	names := []string{}
+	for _, b := range s.Bindings {
+		names = append(names, b.Name+":"+b.Kind.String())
+	}
+	return strings.Join(names, " ")
+}
+
+func undefined(s *Scope) string {
+	names := []string{}
+	for _, ref := range s.Undefined() {
+		names = append(names, s.text(ref.Token))
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+func TestBindings(t *testing.T) {
+	file := analyse()
+	if len(file.Children) != 4 {
+		t.Fatalf("expected 4 child scopes, got %d", len(file.Children))
+	}
+	f := file.Children[0]
+	closure, arrow := f.Children[0], f.Children[1]
+	inner := arrow.Children[0]
+	method, staticMethod := file.Children[1], file.Children[2]
+
+	tests := []struct {
+		name     string
+		scope    *Scope
+		kind     Kind
+		bindings string
+		undef    string
+	}{
+		{"file", file, File, "$top:Assignment $_GET:Superglobal", ""},
+		{"function", f, Function,
+			"$a:Parameter $b:Parameter $c:Parameter $g:Global $s:Static " +
+				"$l1:ListDestructuring $l2:ListDestructuring $k1:ListDestructuring $k2:ListDestructuring " +
+				"$arr:Assignment $key:ForeachKey $value:ForeachValue $e:Catch $fn:Assignment " +
+				"$byRef:ClosureUse $af:Assignment",
+			"$missing $top"},
+		{"closure", closure, Closure, "$p:Parameter $k1:ClosureUse $byRef:ClosureUse $missing:ClosureUse", "$this"},
+		{"arrow", arrow, ArrowFunction, "$q:Parameter $key:ArrowCapture", ""},
+		{"inner arrow", inner, ArrowFunction, "$q:ArrowCapture $key:ArrowCapture", "$undefined"},
+		{"method arrow", method.Children[0], ArrowFunction, "$this:This", ""},
+		{"static method", staticMethod, Method, "", "$this"},
+	}
+	for _, test := range tests {
+		if test.scope.Kind != test.kind {
+			t.Errorf("%s: expected kind %s, got %s", test.name, test.kind, test.scope.Kind)
+		}
+		if got := bindings(test.scope); got != test.bindings {
+			t.Errorf("%s: expected bindings %q, got %q", test.name, test.bindings, got)
+		}
+		if got := undefined(test.scope); got != test.undef {
+			t.Errorf("%s: expected undefined %q, got %q", test.name, test.undef, got)
+		}
+	}
+
+	if b := f.Lookup("$key"); len(b.References) != 1 || b.References[0].Scope != f {
+		t.Errorf("expected $key to be read once where the arrow function captures it")
+	}
+	if b := closure.Lookup("$k1"); b.Captured != f.Lookup("$k1") {
+		t.Errorf("expected closure $k1 to capture the function's $k1")
+	}
+	if b := f.Lookup("$value"); len(b.References) != 0 {
+		t.Errorf("expected $value to be unused")
+	}
+	if method.Lookup("$this") == nil || method.Lookup("$this").Kind != This {
+		t.Errorf("expected the arrow function to capture $this from the method")
+	}
+	if f.Dynamic || !file.Children[3].Dynamic {
+		t.Errorf("expected only dynamic() to be dynamic")
+	}
+}
+
+func TestWritesWithoutRead(t *testing.T) {
+	src := []byte(`<?php unset($u); isset($v, $a['k']); $w ??= 1; $z .= 'a'; $v .= $r;`)
+	file := Analyse(parser.Parse(src), src)
+	if got := bindings(file); got != "$u:Unset $v:Isset $a:Isset $w:Assignment $z:Assignment" {
+		t.Errorf("unexpected bindings %q", got)
+	}
+	if got := undefined(file); got != "$r" {
+		t.Errorf("expected only $r to be undefined, got %q", got)
+	}
+}
@@ -0,0 +1,150 @@
+// Package template splits PHP templates, which mix inline HTML and PHP, into
+// HTML and PHP regions, finds the expressions echoed by <?= ?> tags and
+// builds the HTML of a template as a document of its own for HTML tooling.
+package template
+
+import (
+	"sort"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+type Kind uint8
+
+const (
+	HTML Kind = iota
+	PHP
+)
+
+//go:generate stringer -type=Kind
+
+// Region is the byte range of a run of inline HTML, or of PHP from its open
+// tag to its close tag, both included
+type Region struct {
+	Kind  Kind
+	Start int
+	End   int
+}
+
+// Echo is a <?= ?> tag, from its open tag to its close tag or the end of the
+// source
+type Echo struct {
+	Start int
+	End   int
+	// Phrase is the EchoIntrinsic of the tag
+	Phrase *phrase.Phrase
+	// Expressions are the expressions echoed, phrases or literal tokens
+	Expressions []phrase.AstNode
+}
+
+type splitter struct {
+	regions []Region
+	echoes  []Echo
+	// echo is the index of the echo whose close tag is still to come, or -1
+	echo int
+}
+
+func split(root *phrase.Phrase) *splitter {
+	s := &splitter{regions: []Region{}, echoes: []Echo{}, echo: -1}
+	s.visit(root)
+	return s
+}
+
+// Regions returns the regions of the source of root, in order and covering
+// the whole source. Blocks of alternative syntax such as if: ... endif; and
+// the HTML inside them are regions as they come.
+func Regions(root *phrase.Phrase) []Region {
+	return split(root).regions
+}
+
+// Echoes returns the <?= ?> tags of the source of root, in order
+func Echoes(root *phrase.Phrase) []Echo {
+	return split(root).echoes
+}
+
+func (s *splitter) visit(node phrase.AstNode) {
+	var children []phrase.AstNode
+	switch node := node.(type) {
+	case *lexer.Token:
+		s.token(node)
+		return
+	case *phrase.Phrase:
+		if t := phrase.FirstToken(node); node.Type == phrase.EchoIntrinsic && t != nil && t.Type == lexer.OpenTagEcho {
+			s.echo = len(s.echoes)
+			s.echoes = append(s.echoes, Echo{Start: t.Offset, End: t.Offset, Phrase: node, Expressions: expressions(node)})
+		}
+		children = node.Children
+	case *phrase.ParseError:
+		children = node.Children
+	}
+	for _, child := range children {
+		s.visit(child)
+	}
+}
+
+func (s *splitter) token(t *lexer.Token) {
+	kind := PHP
+	if t.Type == lexer.Text {
+		kind = HTML
+	}
+	end := t.Offset + t.Length
+	if n := len(s.regions); n > 0 && s.regions[n-1].Kind == kind {
+		s.regions[n-1].End = end
+	} else {
+		s.regions = append(s.regions, Region{kind, t.Offset, end})
+	}
+
+	if s.echo >= 0 && kind == PHP {
+		s.echoes[s.echo].End = end
+		if t.Type == lexer.CloseTag {
+			s.echo = -1
+		}
+	}
+}
+
+// expressions returns the expressions of the ExpressionList of an echo
+func expressions(echo *phrase.Phrase) []phrase.AstNode {
+	result := []phrase.AstNode{}
+	for _, child := range echo.Children {
+		if list, ok := child.(*phrase.Phrase); ok && list.Type == phrase.ExpressionList {
+			for _, expr := range list.Children {
+				// literals are bare tokens
+				if t, ok := expr.(*lexer.Token); ok &&
+					(t.Type == lexer.Comma || t.Type == lexer.Comment || t.Type == lexer.Whitespace) {
+					continue
+				}
+				result = append(result, expr)
+			}
+		}
+	}
+	return result
+}
+
+// At returns the index of the region containing offset, the last region for
+// the end of the source and -1 when there are no regions
+func At(regions []Region, offset int) int {
+	i := sort.Search(len(regions), func(i int) bool { return regions[i].End > offset })
+	if i == len(regions) {
+		i--
+	}
+	return i
+}
+
+// HTMLDocument returns the HTML of source as a document of the same length
+// in which PHP is blanked out with spaces, keeping its line breaks, so that
+// offsets, lines and columns in it are those of source
+func HTMLDocument(source []byte, regions []Region) []byte {
+	html := append([]byte(nil), source...)
+	for _, r := range regions {
+		if r.Kind != PHP {
+			continue
+		}
+		for i := r.Start; i < r.End && i < len(html); i++ {
+			if html[i] != '\n' && html[i] != '\r' {
+				html[i] = ' '
+			}
+		}
+	}
+	return html
+}
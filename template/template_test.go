@@ -0,0 +1,120 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/john-nguyen09/go-phpparser/lexer"
+	"github.com/john-nguyen09/go-phpparser/parser"
+	"github.com/john-nguyen09/go-phpparser/phrase"
+)
+
+const source = `<ul>
+<?php foreach ($items as $item): ?>
+  <li class="<?= $item->class ?>"><?= $item->name, $sep; ?></li>
+<?php endforeach; ?>
+</ul>
+<?= $footer`
+
+func TestRegions(t *testing.T) {
+	root := parser.Parse([]byte(source))
+	actual := []string{}
+	for _, r := range Regions(root) {
+		actual = append(actual, r.Kind.String()+" "+source[r.Start:r.End])
+	}
+	expected := []string{
+		"HTML <ul>\n",
+		"PHP <?php foreach ($items as $item): ?>",
+		"HTML \n  <li class=\"",
+		"PHP <?= $item->class ?>",
+		"HTML \">",
+		"PHP <?= $item->name, $sep; ?>",
+		"HTML </li>\n",
+		"PHP <?php endforeach; ?>",
+		"HTML \n</ul>\n",
+		"PHP <?= $footer",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	regions := Regions(root)
+	for offset, expected := range map[int]int{0: 0, 4: 0, 5: 1, 39: 1, 40: 2, len(source): len(regions) - 1} {
+		if actual := At(regions, offset); actual != expected {
+			t.Errorf("offset %d: expected region %d, got %d", offset, expected, actual)
+		}
+	}
+	if At(nil, 0) != -1 {
+		t.Errorf("expected no region without regions")
+	}
+}
+
+// echoes describes the echoes of source with their expressions
+func echoes(t *testing.T, source string) []string {
+	actual := []string{}
+	for _, echo := range Echoes(parser.Parse([]byte(source))) {
+		s := source[echo.Start:echo.End] + " ->"
+		for _, expr := range echo.Expressions {
+			first, last := phrase.FirstToken(expr), phrase.LastToken(expr)
+			kind := ""
+			switch expr := expr.(type) {
+			case *phrase.Phrase:
+				kind = expr.Type.String()
+			case *lexer.Token:
+				kind = expr.Type.String()
+			}
+			s += " " + kind + " " + source[first.Offset:last.Offset+last.Length]
+		}
+		if echo.Phrase.Type != phrase.EchoIntrinsic {
+			t.Errorf("unexpected phrase %v", echo.Phrase.Type)
+		}
+		actual = append(actual, s)
+	}
+	return actual
+}
+
+func TestEchoes(t *testing.T) {
+	actual := echoes(t, source)
+	expected := []string{
+		"<?= $item->class ?> -> PropertyAccessExpression $item->class",
+		"<?= $item->name, $sep; ?> -> PropertyAccessExpression $item->name SimpleVariable $sep",
+		"<?= $footer -> SimpleVariable $footer",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	// literals are tokens
+	actual = echoes(t, `<?= 1, 'a', /* b */ $c ?><?= "x" ?>`)
+	expected = []string{
+		"<?= 1, 'a', /* b */ $c ?> -> IntegerLiteral 1 StringLiteral 'a' SimpleVariable $c",
+		`<?= "x" ?> -> StringLiteral "x"`,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestHTMLDocument(t *testing.T) {
+	src := []byte(source)
+	html := HTMLDocument(src, Regions(parser.Parse(src)))
+	expected := "<ul>\n" +
+		"                                   \n" +
+		"  <li class=\"                   \">                         </li>\n" +
+		"                    \n" +
+		"</ul>\n" +
+		"           "
+	if string(html) != expected {
+		t.Errorf("expected %q, got %q", expected, html)
+	}
+	if string(src) != source {
+		t.Errorf("expected the source to be left as it was")
+	}
+
+	// the HTML lexes as inline text only
+	for it := lexer.Tokens(html); it.Next(); {
+		if !it.Is(lexer.Text) {
+			t.Errorf("unexpected token %v", it.Token())
+		}
+	}
+}